@@ -0,0 +1,242 @@
+package mepost
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Secret is a sensitive string, such as an API key or token. It redacts itself
+// when printed with any fmt verb, encoded as JSON or text, or logged.
+// Use Value to obtain the underlying string.
+type Secret string
+
+const redacted = "[REDACTED]"
+
+// Value returns the secret itself.
+func (s Secret) Value() string {
+	return string(s)
+}
+
+// String returns a redacted placeholder, or an empty string if the secret is empty.
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+// GoString returns a redacted placeholder for the %#v verb.
+func (s Secret) GoString() string {
+	return strconv.Quote(s.String())
+}
+
+// Format implements fmt.Formatter so that no verb can print the secret.
+func (s Secret) Format(f fmt.State, verb rune) {
+	switch verb {
+	case 'q':
+		io.WriteString(f, strconv.Quote(s.String()))
+	case 'v':
+		if f.Flag('#') {
+			io.WriteString(f, s.GoString())
+			return
+		}
+		io.WriteString(f, s.String())
+	default:
+		io.WriteString(f, s.String())
+	}
+}
+
+// MarshalJSON encodes the secret as a redacted placeholder.
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(s.String())), nil
+}
+
+// MarshalText encodes the secret as a redacted placeholder.
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// LogValue implements slog.LogValuer so that the secret is redacted in structured logs.
+func (s Secret) LogValue() slog.Value {
+	return slog.StringValue(s.String())
+}
+
+// UnmarshalText sets the secret to text, so that secrets can be decoded from
+// configuration files.
+func (s *Secret) UnmarshalText(text []byte) error {
+	*s = Secret(text)
+	return nil
+}
+
+// Authenticator adds credentials to API requests.
+type Authenticator interface {
+	Authenticate(ctx context.Context, req *http.Request) error
+}
+
+// AuthenticatorFunc adapts a function to the Authenticator interface.
+type AuthenticatorFunc func(ctx context.Context, req *http.Request) error
+
+// Authenticate calls f(ctx, req).
+func (f AuthenticatorFunc) Authenticate(ctx context.Context, req *http.Request) error {
+	return f(ctx, req)
+}
+
+// invalidator is implemented by authenticators that cache credentials. The
+// client calls Invalidate when the API rejects the credentials, so that they
+// are refreshed before the next request.
+type invalidator interface {
+	Invalidate()
+}
+
+// APIKeyAuth returns an Authenticator that sends key in the Authorization header.
+// This is the scheme used when Client.Auth is nil.
+func APIKeyAuth(key Secret) Authenticator {
+	return AuthenticatorFunc(func(ctx context.Context, req *http.Request) error {
+		req.Header.Set("Authorization", key.Value())
+		return nil
+	})
+}
+
+// CallbackAuth returns an Authenticator that obtains the API key from fn before
+// every request, e.g. from a secrets manager client that handles rotation.
+func CallbackAuth(fn func(ctx context.Context) (Secret, error)) Authenticator {
+	return AuthenticatorFunc(func(ctx context.Context, req *http.Request) error {
+		key, err := fn(ctx)
+		if err != nil {
+			return fmt.Errorf("error obtaining API key: %v", err)
+		}
+		req.Header.Set("Authorization", key.Value())
+		return nil
+	})
+}
+
+// TokenSource returns a bearer token and the time at which it expires.
+// A zero expiry means the token does not expire.
+type TokenSource func(ctx context.Context) (token Secret, expiry time.Time, err error)
+
+// BearerAuthenticator sends a bearer token obtained from a TokenSource, and
+// refreshes it shortly before it expires or after the API rejects it.
+type BearerAuthenticator struct {
+	source TokenSource
+	// RefreshBefore is how long before expiry the token is refreshed.
+	RefreshBefore time.Duration
+
+	mu     sync.Mutex
+	token  Secret
+	expiry time.Time
+	valid  bool
+}
+
+// BearerAuth returns an Authenticator that sends tokens from source using the Bearer scheme.
+func BearerAuth(source TokenSource) *BearerAuthenticator {
+	return &BearerAuthenticator{source: source, RefreshBefore: 30 * time.Second}
+}
+
+// BearerToken returns an Authenticator that sends a fixed token using the Bearer scheme.
+func BearerToken(token Secret) *BearerAuthenticator {
+	return BearerAuth(func(context.Context) (Secret, time.Time, error) {
+		return token, time.Time{}, nil
+	})
+}
+
+// Authenticate implements Authenticator.
+func (a *BearerAuthenticator) Authenticate(ctx context.Context, req *http.Request) error {
+	token, err := a.current(ctx)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token.Value())
+	return nil
+}
+
+func (a *BearerAuthenticator) current(ctx context.Context) (Secret, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.valid && (a.expiry.IsZero() || time.Now().Add(a.RefreshBefore).Before(a.expiry)) {
+		return a.token, nil
+	}
+	token, expiry, err := a.source(ctx)
+	if err != nil {
+		return "", fmt.Errorf("error refreshing bearer token: %v", err)
+	}
+	a.token, a.expiry, a.valid = token, expiry, true
+	return token, nil
+}
+
+// Invalidate discards the cached token, so that the next request obtains a new one.
+func (a *BearerAuthenticator) Invalidate() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.valid = false
+}
+
+// FileAuthenticator reads the API key from a file and reloads it when the file changes,
+// which suits keys mounted by a secrets manager.
+type FileAuthenticator struct {
+	// Path is the file holding the key. Surrounding whitespace is ignored.
+	Path string
+	// Scheme, if set, is sent before the key, e.g. "Bearer".
+	Scheme string
+
+	mu      sync.Mutex
+	key     Secret
+	modTime time.Time
+	size    int64
+}
+
+// FileAuth returns an Authenticator that reads the API key from the file at path.
+func FileAuth(path string) *FileAuthenticator {
+	return &FileAuthenticator{Path: path}
+}
+
+// Authenticate implements Authenticator.
+func (a *FileAuthenticator) Authenticate(ctx context.Context, req *http.Request) error {
+	key, err := a.load()
+	if err != nil {
+		return err
+	}
+	value := key.Value()
+	if a.Scheme != "" {
+		value = a.Scheme + " " + value
+	}
+	req.Header.Set("Authorization", value)
+	return nil
+}
+
+func (a *FileAuthenticator) load() (Secret, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	info, err := os.Stat(a.Path)
+	if err != nil {
+		return "", fmt.Errorf("error reading API key file: %v", err)
+	}
+	if a.key != "" && info.ModTime().Equal(a.modTime) && info.Size() == a.size {
+		return a.key, nil
+	}
+	data, err := os.ReadFile(a.Path)
+	if err != nil {
+		return "", fmt.Errorf("error reading API key file: %v", err)
+	}
+	key := Secret(bytes.TrimSpace(data))
+	if key == "" {
+		return "", errors.New("error reading API key file: file is empty")
+	}
+	a.key, a.modTime, a.size = key, info.ModTime(), info.Size()
+	return key, nil
+}
+
+// Invalidate forces the key to be read again on the next request.
+func (a *FileAuthenticator) Invalidate() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.key = ""
+}
@@ -0,0 +1,175 @@
+package mepost
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// authorization returns the Authorization header a sets.
+func authorization(t *testing.T, a Authenticator) string {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "https://api.example.com", nil)
+	if err := a.Authenticate(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	return req.Header.Get("Authorization")
+}
+
+// tokenSource returns numbered tokens that expire after the durations in
+// lifetimes, counting its calls.
+func tokenSource(calls *atomic.Int32, lifetimes ...time.Duration) TokenSource {
+	return func(context.Context) (Secret, time.Time, error) {
+		n := int(calls.Add(1))
+		var expiry time.Time
+		if n <= len(lifetimes) && lifetimes[n-1] > 0 {
+			expiry = time.Now().Add(lifetimes[n-1])
+		}
+		return Secret(fmt.Sprint("token-", n)), expiry, nil
+	}
+}
+
+func TestBearerRefresh(t *testing.T) {
+	var calls atomic.Int32
+	// The first token is fresh, the second expires within RefreshBefore, and
+	// the third does not expire.
+	a := BearerAuth(tokenSource(&calls, time.Hour, 10*time.Second, 0))
+
+	for i := 0; i < 2; i++ {
+		if got := authorization(t, a); got != "Bearer token-1" {
+			t.Fatalf("got %q, want the first token until it nears expiry", got)
+		}
+	}
+	a.Invalidate()
+	if got := authorization(t, a); got != "Bearer token-2" {
+		t.Fatalf("got %q after Invalidate, want a new token", got)
+	}
+	// token-2 expires within the 30s RefreshBefore, so it is replaced at once.
+	if got := authorization(t, a); got != "Bearer token-3" {
+		t.Fatalf("got %q, want a token refreshed before expiry", got)
+	}
+	if got := authorization(t, a); got != "Bearer token-3" || calls.Load() != 3 {
+		t.Fatalf("got %q after %d refreshes, want token-3 kept as it does not expire", got, calls.Load())
+	}
+
+	failing := BearerAuth(func(context.Context) (Secret, time.Time, error) {
+		return "", time.Time{}, errors.New("identity provider down")
+	})
+	req := httptest.NewRequest(http.MethodGet, "https://api.example.com", nil)
+	if err := failing.Authenticate(context.Background(), req); err == nil || req.Header.Get("Authorization") != "" {
+		t.Errorf("got error %v and header %q from a failing source", err, req.Header.Get("Authorization"))
+	}
+}
+
+func TestBearerConcurrentRefresh(t *testing.T) {
+	var calls atomic.Int32
+	source := tokenSource(&calls)
+	a := BearerAuth(func(ctx context.Context) (Secret, time.Time, error) {
+		time.Sleep(10 * time.Millisecond)
+		return source(ctx)
+	})
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got := authorization(t, a); got != "Bearer token-1" {
+				t.Errorf("got %q, want the single refreshed token", got)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := calls.Load(); n != 1 {
+		t.Errorf("token source called %d times by concurrent requests, want once", n)
+	}
+}
+
+func TestInvalidateAfterUnauthorized(t *testing.T) {
+	var rejected atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The first token has been revoked.
+		if r.Header.Get("Authorization") == "Bearer token-1" {
+			rejected.Store(true)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+
+	var calls atomic.Int32
+	c := NewClient("")
+	c.BaseURL = srv.URL
+	c.Auth = BearerAuth(tokenSource(&calls, time.Hour, time.Hour))
+	ctx := context.Background()
+	var apiErr *APIError
+	if _, err := c.Outbound.IPGroups.List(ctx); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("got error %v, want 401", err)
+	}
+	if _, err := c.Outbound.IPGroups.List(ctx); err != nil {
+		t.Fatalf("request after the 401: %v", err)
+	}
+	if !rejected.Load() || calls.Load() != 2 {
+		t.Errorf("got %d tokens, want the token refreshed after the 401", calls.Load())
+	}
+}
+
+func TestFileAuthReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api-key")
+	write := func(key string, modTime time.Time) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(key), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	start := time.Now().Add(-time.Hour)
+	write("key-one\n", start)
+	a := FileAuth(path)
+	if got := authorization(t, a); got != "key-one" {
+		t.Fatalf("got %q, want the trimmed key", got)
+	}
+
+	// A rotated key of the same length is noticed by its modification time.
+	write("key-two\n", start.Add(time.Second))
+	if got := authorization(t, a); got != "key-two" {
+		t.Fatalf("got %q after the file changed, want the new key", got)
+	}
+	// Rewriting the file keeping its size and modification time goes unnoticed
+	// until Invalidate.
+	write("key-new\n", start.Add(time.Second))
+	if got := authorization(t, a); got != "key-two" {
+		t.Fatalf("got %q for a file that looks unchanged, want the cached key", got)
+	}
+	a.Invalidate()
+	if got := authorization(t, a); got != "key-new" {
+		t.Fatalf("got %q after Invalidate, want the key read again", got)
+	}
+
+	a.Scheme = "Bearer"
+	write("key-three", start.Add(2*time.Second))
+	if got := authorization(t, a); got != "Bearer key-three" {
+		t.Errorf("got %q, want the key after the scheme", got)
+	}
+
+	for name, content := range map[string]string{"empty": " \n", "missing": ""} {
+		p := filepath.Join(t.TempDir(), name)
+		if content != "" {
+			os.WriteFile(p, []byte(content), 0o600)
+		}
+		req := httptest.NewRequest(http.MethodGet, "https://api.example.com", nil)
+		if err := FileAuth(p).Authenticate(context.Background(), req); err == nil {
+			t.Errorf("%s file: got no error", name)
+		}
+	}
+}
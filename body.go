@@ -0,0 +1,188 @@
+package mepost
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DefaultMaxResponseSize is the response size limit used when Client.MaxResponseSize is zero.
+const DefaultMaxResponseSize = 64 << 20
+
+// ErrResponseTooLarge is returned, wrapped, when a response body exceeds the client's MaxResponseSize.
+var ErrResponseTooLarge = errors.New("mepost: response body exceeds the maximum size")
+
+// encodeError marks a failure to encode the request body, so that it is not
+// mistaken for a transport error by the retry logic.
+type encodeError struct {
+	err error
+}
+
+func (e *encodeError) Error() string {
+	return e.err.Error()
+}
+
+// requestBody is an encoded request body. Bodies are encoded in memory and sent
+// with their length, unless Client.StreamRequests is set.
+type requestBody struct {
+	// data is the encoded body, unless it is streamed.
+	data []byte
+	// stream is the body when it is streamed.
+	stream io.ReadCloser
+	// gzipped reports whether the body is gzip-compressed.
+	gzipped bool
+}
+
+// reader returns a reader of the body.
+func (b requestBody) reader() io.ReadCloser {
+	switch {
+	case b.stream != nil:
+		return b.stream
+	case b.data != nil:
+		return io.NopCloser(bytes.NewReader(b.data))
+	}
+	return http.NoBody
+}
+
+// encodeRequest encodes the body of a request for v. When compress is set,
+// bodies above the compression threshold are gzipped.
+func (c *Client) encodeRequest(v interface{}, compress bool) (requestBody, error) {
+	switch {
+	case v == nil:
+		return requestBody{}, nil
+	case c.StreamRequests:
+		return c.streamRequest(v, compress)
+	}
+	data, err := c.codec().Marshal(v)
+	if err != nil {
+		return requestBody{}, err
+	}
+	if compress && len(data) > c.Compression.Threshold {
+		data, err = gzipBytes(data, c.compressionLevel())
+		return requestBody{data: data, gzipped: true}, err
+	}
+	return requestBody{data: data}, nil
+}
+
+// encodeBody returns a reader that streams the encoding of v. The value is
+// encoded by a goroutine as the transport reads the body, so the encoding is
+// never held in memory alongside the request.
+func encodeBody(codec Codec, v interface{}) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		if err := codec.NewEncoder(pw).Encode(v); err != nil {
+			pw.CloseWithError(&encodeError{err})
+			return
+		}
+		pw.Close()
+	}()
+	return pr
+}
+
+// maxResponseSize returns the response size limit, or -1 for none.
+func (c *Client) maxResponseSize() int64 {
+	switch {
+	case c.MaxResponseSize == 0:
+		return DefaultMaxResponseSize
+	case c.MaxResponseSize < 0:
+		return -1
+	}
+	return c.MaxResponseSize
+}
+
+// readResponse decodes a successful response body into response. Bodies of
+// known length are read once into a buffer of that size and decoded from there,
+// and others are decoded as they are read, unless the body is kept or unknown
+// fields are collected for Client.StrictDecoding or Client.KeepRawResponse. The body of an error
+// response is read in full and not decoded. The returned bytes are the error
+// response body, or the raw body of a successful response when it is kept or
+// logged.
+func (c *Client) readResponse(resp *http.Response, response interface{}, keepBody bool) ([]byte, error) {
+	var body io.Reader = resp.Body
+	if strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") && !resp.Uncompressed {
+		zr, err := gzip.NewReader(resp.Body)
+		switch {
+		case err == io.EOF:
+			body = http.NoBody
+		case err != nil:
+			return nil, fmt.Errorf("error reading response body: %w", err)
+		default:
+			defer zr.Close()
+			body = zr
+		}
+	}
+	// The limit applies to the decompressed body.
+	if limit := c.maxResponseSize(); limit >= 0 {
+		body = &limitedReader{r: body, n: limit}
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return data, fmt.Errorf("error reading response body: %w", err)
+		}
+		return data, nil
+	}
+
+	extra := (c.StrictDecoding || c.KeepRawResponse) && collectsExtra(response)
+	// A body of known length is read into a buffer of that size, which costs
+	// less than the growing buffer of a streaming decoder.
+	sized := resp.ContentLength > 0 && (c.maxResponseSize() < 0 || resp.ContentLength <= c.maxResponseSize())
+	if !extra && !keepBody && !sized {
+		return nil, decodeError(c.codec().NewDecoder(body).Decode(response))
+	}
+	buf := &bytes.Buffer{}
+	if sized {
+		buf.Grow(int(resp.ContentLength) + bytes.MinRead)
+	}
+	if _, err := buf.ReadFrom(body); err != nil {
+		return nil, fmt.Errorf("error reading response body: %w", err)
+	}
+	data := buf.Bytes()
+	if len(bytes.TrimSpace(data)) == 0 {
+		// An empty body leaves the response unchanged.
+		return nil, nil
+	}
+	err := c.codec().Unmarshal(data, response)
+	if err == nil && extra {
+		err = collectExtra(c.codec(), data, response)
+	}
+	if keepBody {
+		return data, decodeError(err)
+	}
+	return nil, decodeError(err)
+}
+
+func decodeError(err error) error {
+	// An empty body leaves the response unchanged.
+	if err == nil || err == io.EOF {
+		return nil
+	}
+	return fmt.Errorf("error unmarshalling response: %w", err)
+}
+
+// limitedReader reads from r until n bytes have been read and then fails with
+// ErrResponseTooLarge, unlike io.LimitedReader which reports a silent EOF.
+type limitedReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n < 0 {
+		return 0, ErrResponseTooLarge
+	}
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	if l.n < 0 {
+		return n + int(l.n), ErrResponseTooLarge
+	}
+	return n, err
+}
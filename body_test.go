@@ -0,0 +1,329 @@
+package mepost
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// benchTransport answers every request with body after draining the request body,
+// so that benchmarks measure the client and not the network.
+type benchTransport struct {
+	body   []byte
+	header http.Header
+	// sent counts the request body bytes written to the transport.
+	sent int64
+	// chunked hides the length of the response body, as chunked responses do.
+	chunked bool
+}
+
+func (t *benchTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		n, _ := io.Copy(io.Discard, req.Body)
+		t.sent += n
+		req.Body.Close()
+	}
+	header := t.header
+	if header == nil {
+		header = http.Header{"Content-Type": {"application/json"}}
+	}
+	length := int64(len(t.body))
+	if t.chunked {
+		length = -1
+	}
+	return &http.Response{
+		StatusCode:    http.StatusOK,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(t.body)),
+		ContentLength: length,
+		Request:       req,
+	}, nil
+}
+
+// legacyRequest is the request pipeline of earlier releases: the request is
+// marshalled into a buffer and the response read in full before unmarshalling.
+func legacyRequest(client *http.Client, method, url string, requestData, response interface{}) error {
+	var jsonData []byte
+	var err error
+	if requestData != nil {
+		jsonData, err = json.Marshal(requestData)
+		if err != nil {
+			return fmt.Errorf("error marshalling request data: %v", err)
+		}
+	}
+	req, err := http.NewRequest(method, url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("error creating request: %v", err)
+	}
+	req.Header.Set("Authorization", "key")
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error making request: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response body: %v", err)
+	}
+	return json.Unmarshal(body, &response)
+}
+
+// subscriberPage returns the JSON of a page of n subscribers.
+func subscriberPage(n int) []byte {
+	var b bytes.Buffer
+	b.WriteString(`{"data":[`)
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, `{"bounced":false,"confirmCode":"c%[1]d","confirmIp":"10.0.0.1","confirmed":true,`+
+			`"createdAt":"2024-03-01T10:00:00.000Z","customFields":[{"name":"plan","value":"pro"},{"name":"city","value":"Berlin"}],`+
+			`"emailAddress":"user%[1]d@example.com","emailGroupId":7,"subscribedAt":"2024-03-01T10:00:00.000Z",`+
+			`"unsubscribed":false,"updatedAt":"2024-03-02T10:00:00.000Z","uuid":"00000000-0000-0000-0000-%012[1]d"}`, i)
+	}
+	fmt.Fprintf(&b, `],"total":%d}`, n)
+	return b.Bytes()
+}
+
+// attachmentRequest returns a transactional send with n attachments of size bytes each.
+func attachmentRequest(n, size int) SendTransactionalRequest {
+	content := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte("attachment data "), size/16))
+	request := SendTransactionalRequest{
+		FromEmail: "noreply@example.com",
+		FromName:  "Example",
+		Subject:   "Your documents",
+		Html:      "<p>See attached.</p>",
+		To:        []To{{Email: "alice@example.com", Name: "Alice"}},
+	}
+	for i := 0; i < n; i++ {
+		request.Attachments = append(request.Attachments, AttachmentDto{
+			FileName:      fmt.Sprintf("document-%d.pdf", i),
+			Base64Content: content,
+		})
+	}
+	return request
+}
+
+func benchClient(body []byte) *Client {
+	c := NewClient("key")
+	c.BaseURL = "https://api.example.com/v1"
+	c.HTTPClient = &http.Client{Transport: &benchTransport{body: body}}
+	return c
+}
+
+func BenchmarkSubscriberPage(b *testing.B) {
+	page := subscriberPage(10000)
+	b.Run("legacy", func(b *testing.B) {
+		benchmarkLegacyPage(b, page)
+	})
+	for _, chunked := range []bool{false, true} {
+		name := "client"
+		if chunked {
+			name = "client-chunked"
+		}
+		b.Run(name, func(b *testing.B) {
+			benchmarkClientPage(b, page, chunked)
+		})
+	}
+}
+
+func benchmarkLegacyPage(b *testing.B, page []byte) {
+	client := &http.Client{Transport: &benchTransport{body: page}}
+	url := "https://api.example.com/v1/groups/g/subscribers"
+	b.ReportAllocs()
+	b.SetBytes(int64(len(page)))
+	for i := 0; i < b.N; i++ {
+		response := &BaseResult[Subscriber]{}
+		if err := legacyRequest(client, "GET", url, nil, response); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchmarkClientPage(b *testing.B, page []byte, chunked bool) {
+	c := benchClient(page)
+	c.HTTPClient.Transport.(*benchTransport).chunked = chunked
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.SetBytes(int64(len(page)))
+	for i := 0; i < b.N; i++ {
+		if _, err := c.Subscribers.List(ctx, "g", nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// TestSubscriberPageAllocations checks that reading a large page of known
+// length allocates less than the pipeline of earlier releases. Pages of
+// unknown length are stream-decoded, which with encoding/json costs about
+// as much as reading them in full.
+func TestSubscriberPageAllocations(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs benchmarks")
+	}
+	page := subscriberPage(2000)
+	legacy := testing.Benchmark(func(b *testing.B) { benchmarkLegacyPage(b, page) })
+	client := testing.Benchmark(func(b *testing.B) { benchmarkClientPage(b, page, false) })
+	if client.AllocedBytesPerOp() >= legacy.AllocedBytesPerOp() {
+		t.Errorf("client allocates %d bytes per page, legacy %d", client.AllocedBytesPerOp(), legacy.AllocedBytesPerOp())
+	}
+}
+
+func BenchmarkSendAttachments(b *testing.B) {
+	request := attachmentRequest(3, 2<<20)
+	response := []byte(`{"uuid":"s1","state":"scheduled"}`)
+	url := "https://api.example.com/v1/messages/transactional"
+	b.Run("legacy", func(b *testing.B) {
+		client := &http.Client{Transport: &benchTransport{body: response}}
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := legacyRequest(client, "POST", url, request, &Schedule{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	for _, stream := range []bool{false, true} {
+		name := "client"
+		if stream {
+			name = "client-streamed"
+		}
+		b.Run(name, func(b *testing.B) {
+			c := benchClient(response)
+			c.StreamRequests = stream
+			ctx := context.Background()
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := c.Messages.SendTransactional(ctx, request); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestRequestBodyLength(t *testing.T) {
+	type received struct {
+		length   int64
+		chunked  bool
+		bodySize int
+	}
+	var got received
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got = received{r.ContentLength, len(r.TransferEncoding) > 0 && r.TransferEncoding[0] == "chunked", len(body)}
+		w.Write([]byte(`{"uuid":"s1"}`))
+	}))
+	defer srv.Close()
+	c := NewClient("key")
+	c.BaseURL = srv.URL
+	ctx := context.Background()
+	request := attachmentRequest(1, 64<<10)
+
+	if _, err := c.Messages.SendTransactional(ctx, request); err != nil {
+		t.Fatal(err)
+	}
+	if got.chunked || got.length != int64(got.bodySize) {
+		t.Errorf("buffered request: got %+v, want a Content-Length of the body size", got)
+	}
+
+	c.StreamRequests = true
+	if _, err := c.Messages.SendTransactional(ctx, request); err != nil {
+		t.Fatal(err)
+	}
+	if !got.chunked || got.length != -1 {
+		t.Errorf("streamed request: got %+v, want a chunked body", got)
+	}
+}
+
+func TestReadResponse(t *testing.T) {
+	page := `{"data":[{"emailAddress":"a@example.com","score":3}],"total":1,"cursor":"next"}`
+	tests := []struct {
+		name    string
+		body    string
+		maxSize int64
+		strict  bool
+		keepRaw bool
+		check   func(*testing.T, *BaseResult[Subscriber], error)
+	}{
+		{
+			name: "streamed",
+			body: page,
+			check: func(t *testing.T, r *BaseResult[Subscriber], err error) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if r.Data[0].EmailAddress != "a@example.com" {
+					t.Errorf("got address %q", r.Data[0].EmailAddress)
+				}
+				if r.Extra != nil || r.Data[0].Extra != nil || r.Raw != nil {
+					t.Errorf("got extra %v and %v and raw body %s without opting in", r.Extra, r.Data[0].Extra, r.Raw)
+				}
+			},
+		},
+		{
+			name:    "unknown fields",
+			body:    page,
+			keepRaw: true,
+			check: func(t *testing.T, r *BaseResult[Subscriber], err error) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(r.Extra["cursor"]) != `"next"` || string(r.Data[0].Extra["score"]) != "3" {
+					t.Errorf("got extra %v and %v", r.Extra, r.Data[0].Extra)
+				}
+				if r.Data[0].EmailAddress != "a@example.com" || string(r.Raw) != page {
+					t.Errorf("got address %q and raw body %s", r.Data[0].EmailAddress, r.Raw)
+				}
+			},
+		},
+		{
+			name:   "strict",
+			body:   page,
+			strict: true,
+			check: func(t *testing.T, r *BaseResult[Subscriber], err error) {
+				var unknown *UnknownFieldsError
+				if !errors.As(err, &unknown) || strings.Join(unknown.Fields, ",") != "cursor,data[0].score" {
+					t.Errorf("got error %v", err)
+				}
+			},
+		},
+		{
+			name: "empty",
+			body: "",
+			check: func(t *testing.T, r *BaseResult[Subscriber], err error) {
+				if err != nil || r.Data != nil {
+					t.Errorf("got %+v, %v", r, err)
+				}
+			},
+		},
+		{
+			name:    "too large",
+			body:    page,
+			maxSize: 10,
+			check: func(t *testing.T, r *BaseResult[Subscriber], err error) {
+				if !errors.Is(err, ErrResponseTooLarge) {
+					t.Errorf("got error %v, want ErrResponseTooLarge", err)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := benchClient([]byte(tt.body))
+			c.MaxResponseSize = tt.maxSize
+			c.StrictDecoding = tt.strict
+			c.KeepRawResponse = tt.keepRaw
+			r, err := c.Subscribers.List(context.Background(), "g", nil)
+			tt.check(t, r, err)
+		})
+	}
+}
@@ -0,0 +1,295 @@
+package mepost
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// BreakerState is the state of a circuit breaker.
+type BreakerState int
+
+// Circuit breaker states.
+const (
+	// BreakerClosed lets requests through and counts their failures.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen rejects requests until BreakerPolicy.OpenTimeout has passed.
+	BreakerOpen
+	// BreakerHalfOpen lets a limited number of trial requests through.
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("BreakerState(%d)", int(s))
+}
+
+// ErrCircuitOpen matches every *CircuitOpenError with errors.Is.
+var ErrCircuitOpen = errors.New("mepost: circuit breaker is open")
+
+// CircuitOpenError is returned without making a request while the circuit
+// breaker of the call's endpoint class is open.
+type CircuitOpenError struct {
+	Class EndpointClass
+	// RetryAt is when the breaker lets a trial request through.
+	RetryAt time.Time
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("mepost: circuit breaker for %s requests is open until %s", e.Class, e.RetryAt.Format(time.RFC3339))
+}
+
+// Is reports whether target is ErrCircuitOpen.
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// BreakerPolicy configures a CircuitBreaker. Zero fields take the defaults noted.
+//
+// Transport errors, timeouts and 5xx responses count as failures. Other
+// responses count as successes. Calls canceled by the caller, or whose context
+// deadline expired, are not counted.
+type BreakerPolicy struct {
+	// Window is the sliding window over which the failure rate is measured. Defaults to 30s.
+	Window time.Duration
+	// MinRequests is the number of requests in the window below which the breaker
+	// stays closed whatever the failure rate. Defaults to 10.
+	MinRequests int
+	// FailureRate is the fraction of failed requests, between 0 and 1, at which
+	// the breaker opens. Defaults to 0.5.
+	FailureRate float64
+	// OpenTimeout is how long the breaker stays open before trying again. Defaults to 30s.
+	OpenTimeout time.Duration
+	// HalfOpenRequests is the number of trial requests that must succeed to
+	// close the breaker. Defaults to 1.
+	HalfOpenRequests int
+	// OnStateChange, if set, is called after a breaker changes state.
+	OnStateChange func(class EndpointClass, from, to BreakerState)
+}
+
+// windowBuckets is the number of buckets the sliding window is divided into.
+const windowBuckets = 10
+
+// CircuitBreaker fails calls fast while the Mepost API is failing. It keeps a
+// separate breaker per endpoint class, so that an outage of the send endpoints
+// does not block management calls and vice versa.
+type CircuitBreaker struct {
+	policy BreakerPolicy
+	now    func() time.Time
+
+	mu       sync.Mutex
+	breakers map[EndpointClass]*breaker
+}
+
+// NewCircuitBreaker creates a CircuitBreaker.
+func NewCircuitBreaker(policy BreakerPolicy) *CircuitBreaker {
+	if policy.Window <= 0 {
+		policy.Window = 30 * time.Second
+	}
+	if policy.MinRequests <= 0 {
+		policy.MinRequests = 10
+	}
+	if policy.FailureRate <= 0 || policy.FailureRate > 1 {
+		policy.FailureRate = 0.5
+	}
+	if policy.OpenTimeout <= 0 {
+		policy.OpenTimeout = 30 * time.Second
+	}
+	if policy.HalfOpenRequests <= 0 {
+		policy.HalfOpenRequests = 1
+	}
+	return &CircuitBreaker{policy: policy, now: time.Now, breakers: map[EndpointClass]*breaker{}}
+}
+
+// State returns the current state of the breaker of an endpoint class.
+func (cb *CircuitBreaker) State(class EndpointClass) BreakerState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	b := cb.breaker(class)
+	if b.state == BreakerOpen && !cb.now().Before(b.openedAt.Add(cb.policy.OpenTimeout)) {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
+
+type breaker struct {
+	state      BreakerState
+	generation uint64
+	openedAt   time.Time
+
+	// Closed state: request and failure counts per bucket of the window.
+	buckets    [windowBuckets]struct{ total, failures int }
+	bucketTime time.Time // start of the current bucket
+
+	// Half-open state.
+	trials    int
+	successes int
+}
+
+func (cb *CircuitBreaker) breaker(class EndpointClass) *breaker {
+	b := cb.breakers[class]
+	if b == nil {
+		b = &breaker{}
+		cb.breakers[class] = b
+	}
+	return b
+}
+
+// allow reports whether a request may be made. The returned generation must
+// be passed to record.
+func (cb *CircuitBreaker) allow(class EndpointClass) (uint64, error) {
+	if cb == nil {
+		return 0, nil
+	}
+	cb.mu.Lock()
+	now := cb.now()
+	b := cb.breaker(class)
+	var transition func()
+	if b.state == BreakerOpen {
+		retryAt := b.openedAt.Add(cb.policy.OpenTimeout)
+		if now.Before(retryAt) {
+			cb.mu.Unlock()
+			return 0, &CircuitOpenError{Class: class, RetryAt: retryAt}
+		}
+		transition = cb.setState(class, b, BreakerHalfOpen, now)
+	}
+	if b.state == BreakerHalfOpen {
+		if b.trials >= cb.policy.HalfOpenRequests {
+			cb.mu.Unlock()
+			cb.notify(transition)
+			return 0, &CircuitOpenError{Class: class, RetryAt: now.Add(cb.policy.OpenTimeout)}
+		}
+		b.trials++
+	}
+	generation := b.generation
+	cb.mu.Unlock()
+	cb.notify(transition)
+	return generation, nil
+}
+
+// record counts the outcome of a request allowed in the given generation and
+// made with ctx.
+func (cb *CircuitBreaker) record(ctx context.Context, class EndpointClass, generation uint64, err error) {
+	if cb == nil {
+		return
+	}
+	failed, counted := breakerOutcome(ctx, err)
+	cb.mu.Lock()
+	now := cb.now()
+	b := cb.breaker(class)
+	if generation != b.generation {
+		// The breaker changed state since the request started.
+		cb.mu.Unlock()
+		return
+	}
+	var transition func()
+	switch b.state {
+	case BreakerHalfOpen:
+		switch {
+		case !counted:
+			b.trials--
+		case failed:
+			transition = cb.setState(class, b, BreakerOpen, now)
+		default:
+			b.successes++
+			if b.successes >= cb.policy.HalfOpenRequests {
+				transition = cb.setState(class, b, BreakerClosed, now)
+			}
+		}
+	case BreakerClosed:
+		if counted {
+			bucket := cb.advance(b, now)
+			bucket.total++
+			if failed {
+				bucket.failures++
+			}
+			total, failures := 0, 0
+			for _, bucket := range b.buckets {
+				total += bucket.total
+				failures += bucket.failures
+			}
+			if total >= cb.policy.MinRequests && float64(failures) >= cb.policy.FailureRate*float64(total) {
+				transition = cb.setState(class, b, BreakerOpen, now)
+			}
+		}
+	}
+	cb.mu.Unlock()
+	cb.notify(transition)
+}
+
+// advance rotates the window to now and returns the current bucket.
+func (cb *CircuitBreaker) advance(b *breaker, now time.Time) *struct{ total, failures int } {
+	width := cb.policy.Window / windowBuckets
+	if b.bucketTime.IsZero() {
+		b.bucketTime = now
+	}
+	for elapsed := now.Sub(b.bucketTime); elapsed >= width; elapsed -= width {
+		copy(b.buckets[1:], b.buckets[:windowBuckets-1])
+		b.buckets[0] = struct{ total, failures int }{}
+		b.bucketTime = b.bucketTime.Add(width)
+		if elapsed >= cb.policy.Window+width {
+			// Everything is stale.
+			b.buckets = [windowBuckets]struct{ total, failures int }{}
+			b.bucketTime = now
+			break
+		}
+	}
+	return &b.buckets[0]
+}
+
+// setState changes the state and returns the callback to run after unlocking.
+func (cb *CircuitBreaker) setState(class EndpointClass, b *breaker, state BreakerState, now time.Time) func() {
+	from := b.state
+	b.state = state
+	b.generation++
+	b.trials, b.successes = 0, 0
+	switch state {
+	case BreakerOpen:
+		b.openedAt = now
+	case BreakerClosed:
+		b.buckets = [windowBuckets]struct{ total, failures int }{}
+		b.bucketTime = time.Time{}
+	}
+	if cb.policy.OnStateChange == nil {
+		return nil
+	}
+	return func() { cb.policy.OnStateChange(class, from, state) }
+}
+
+func (cb *CircuitBreaker) notify(transition func()) {
+	if transition != nil {
+		transition()
+	}
+}
+
+// breakerOutcome classifies the result of a request made with ctx.
+func breakerOutcome(ctx context.Context, err error) (failed, counted bool) {
+	if err == nil {
+		return false, true
+	}
+	// Calls canceled by the caller, or that ran out of the caller's time,
+	// say nothing about the API.
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) {
+		return false, false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= 500, true
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) || errors.Is(err, context.DeadlineExceeded) {
+		return true, true
+	}
+	// Errors building or authenticating the request say nothing about the API.
+	return false, false
+}
@@ -0,0 +1,260 @@
+package mepost
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced clock.
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	return c.t
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.t = c.t.Add(d)
+}
+
+// testBreaker returns a breaker on a fake clock that records its transitions.
+func testBreaker(policy BreakerPolicy) (*CircuitBreaker, *fakeClock, *[]string) {
+	var transitions []string
+	policy.OnStateChange = func(class EndpointClass, from, to BreakerState) {
+		transitions = append(transitions, fmt.Sprintf("%s:%s->%s", class, from, to))
+	}
+	clock := &fakeClock{t: time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)}
+	cb := NewCircuitBreaker(policy)
+	cb.now = clock.now
+	return cb, clock, &transitions
+}
+
+var (
+	errServer    = &APIError{StatusCode: http.StatusInternalServerError}
+	errNotFound  = &APIError{StatusCode: http.StatusNotFound}
+	errTransport = &url.Error{Op: "Get", URL: "https://api.example.com", Err: errors.New("connection refused")}
+)
+
+// call runs a request through the breaker with the given outcome.
+func call(t *testing.T, cb *CircuitBreaker, class EndpointClass, err error) {
+	t.Helper()
+	generation, allowErr := cb.allow(class)
+	if allowErr != nil {
+		t.Fatalf("request rejected: %v", allowErr)
+	}
+	cb.record(context.Background(), class, generation, err)
+}
+
+func TestBreakerOutcome(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	live := context.Background()
+	timeout := &url.Error{Op: "Get", URL: "https://api.example.com", Err: context.DeadlineExceeded}
+
+	tests := []struct {
+		name            string
+		ctx             context.Context
+		err             error
+		failed, counted bool
+	}{
+		{"success", live, nil, false, true},
+		{"server error", live, errServer, true, true},
+		{"client error", live, errNotFound, false, true},
+		{"transport error", live, errTransport, true, true},
+		{"transport timeout", live, timeout, true, true},
+		{"caller deadline", expired, timeout, false, false},
+		{"caller deadline unwrapped", expired, context.DeadlineExceeded, false, false},
+		{"caller canceled", canceled, &url.Error{Op: "Get", URL: "u", Err: context.Canceled}, false, false},
+		{"canceled", live, context.Canceled, false, false},
+		{"request not built", live, errors.New("error authenticating request"), false, false},
+	}
+	for _, tt := range tests {
+		failed, counted := breakerOutcome(tt.ctx, tt.err)
+		if failed != tt.failed || counted != tt.counted {
+			t.Errorf("%s: got failed=%v counted=%v, want %v %v", tt.name, failed, counted, tt.failed, tt.counted)
+		}
+	}
+}
+
+func TestBreakerStateMachine(t *testing.T) {
+	cb, clock, transitions := testBreaker(BreakerPolicy{
+		Window:           10 * time.Second,
+		MinRequests:      4,
+		FailureRate:      0.5,
+		OpenTimeout:      5 * time.Second,
+		HalfOpenRequests: 2,
+	})
+
+	// Below MinRequests the breaker stays closed whatever the failure rate.
+	for i := 0; i < 3; i++ {
+		call(t, cb, ClassSend, errServer)
+	}
+	if state := cb.State(ClassSend); state != BreakerClosed {
+		t.Fatalf("got state %s after 3 failures, want closed", state)
+	}
+	call(t, cb, ClassSend, nil)
+	if state := cb.State(ClassSend); state != BreakerOpen {
+		t.Fatalf("got state %s at a failure rate of 0.75, want open", state)
+	}
+	if state := cb.State(ClassManagement); state != BreakerClosed {
+		t.Errorf("management breaker is %s, want closed", state)
+	}
+
+	_, err := cb.allow(ClassSend)
+	var openErr *CircuitOpenError
+	if !errors.As(err, &openErr) || !errors.Is(err, ErrCircuitOpen) || !openErr.RetryAt.Equal(clock.t.Add(5*time.Second)) {
+		t.Fatalf("got error %v while open, want a CircuitOpenError until the open timeout", err)
+	}
+
+	// After the open timeout, HalfOpenRequests trials are let through.
+	clock.advance(5 * time.Second)
+	if state := cb.State(ClassSend); state != BreakerHalfOpen {
+		t.Fatalf("got state %s after the open timeout, want half-open", state)
+	}
+	first, err := cb.allow(ClassSend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := cb.allow(ClassSend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cb.allow(ClassSend); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("third trial got error %v, want ErrCircuitOpen", err)
+	}
+	cb.record(context.Background(), ClassSend, first, nil)
+	if state := cb.State(ClassSend); state != BreakerHalfOpen {
+		t.Fatalf("got state %s after one of two trials succeeded, want half-open", state)
+	}
+	cb.record(context.Background(), ClassSend, second, nil)
+	if state := cb.State(ClassSend); state != BreakerClosed {
+		t.Fatalf("got state %s after the trials succeeded, want closed", state)
+	}
+
+	want := []string{"send:closed->open", "send:open->half-open", "send:half-open->closed"}
+	if fmt.Sprint(*transitions) != fmt.Sprint(want) {
+		t.Errorf("got transitions %v, want %v", *transitions, want)
+	}
+}
+
+func TestBreakerHalfOpenFailure(t *testing.T) {
+	cb, clock, transitions := testBreaker(BreakerPolicy{MinRequests: 1, OpenTimeout: time.Second})
+	call(t, cb, ClassManagement, errTransport)
+	clock.advance(time.Second)
+
+	// A trial canceled by the caller frees its slot.
+	generation, err := cb.allow(ClassManagement)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cb.record(context.Background(), ClassManagement, generation, context.Canceled)
+	generation, err = cb.allow(ClassManagement)
+	if err != nil {
+		t.Fatalf("trial after a canceled trial: %v", err)
+	}
+	cb.record(context.Background(), ClassManagement, generation, errServer)
+	if state := cb.State(ClassManagement); state != BreakerOpen {
+		t.Fatalf("got state %s after a failed trial, want open", state)
+	}
+	want := []string{"management:closed->open", "management:open->half-open", "management:half-open->open"}
+	if fmt.Sprint(*transitions) != fmt.Sprint(want) {
+		t.Errorf("got transitions %v, want %v", *transitions, want)
+	}
+}
+
+func TestBreakerStaleGeneration(t *testing.T) {
+	cb, clock, _ := testBreaker(BreakerPolicy{MinRequests: 1, OpenTimeout: time.Second, HalfOpenRequests: 1})
+	slow, err := cb.allow(ClassSend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	call(t, cb, ClassSend, errServer)
+	clock.advance(time.Second)
+	trial, err := cb.allow(ClassSend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A request that started before the breaker opened does not close it.
+	cb.record(context.Background(), ClassSend, slow, nil)
+	if state := cb.State(ClassSend); state != BreakerHalfOpen {
+		t.Fatalf("got state %s after a stale success, want half-open", state)
+	}
+	cb.record(context.Background(), ClassSend, trial, nil)
+	if state := cb.State(ClassSend); state != BreakerClosed {
+		t.Fatalf("got state %s after the trial succeeded, want closed", state)
+	}
+}
+
+func TestBreakerWindowEviction(t *testing.T) {
+	cb, clock, _ := testBreaker(BreakerPolicy{Window: 10 * time.Second, MinRequests: 4, FailureRate: 0.5})
+	for i := 0; i < 3; i++ {
+		call(t, cb, ClassSend, errServer)
+	}
+	// The failures leave the window once it has rotated past their bucket.
+	clock.advance(10 * time.Second)
+	call(t, cb, ClassSend, errServer)
+	for i := 0; i < 3; i++ {
+		call(t, cb, ClassSend, nil)
+	}
+	if state := cb.State(ClassSend); state != BreakerClosed {
+		t.Fatalf("got state %s with 1 failure in 4 requests in the window, want closed", state)
+	}
+
+	// Failures within the window add up across buckets.
+	for i := 0; i < 2; i++ {
+		clock.advance(time.Second)
+		call(t, cb, ClassSend, errServer)
+	}
+	if state := cb.State(ClassSend); state != BreakerOpen {
+		t.Fatalf("got state %s with 3 failures in 6 requests in the window, want open", state)
+	}
+}
+
+func TestBreakerCallerDeadline(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name          string
+		clientTimeout time.Duration
+		ctxTimeout    time.Duration
+		want          BreakerState
+	}{
+		{"caller deadline", 0, 20 * time.Millisecond, BreakerClosed},
+		{"transport timeout", 20 * time.Millisecond, 0, BreakerOpen},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewClient("key")
+			c.BaseURL = srv.URL
+			c.HTTPClient = &http.Client{Timeout: tt.clientTimeout}
+			c.CircuitBreaker = NewCircuitBreaker(BreakerPolicy{MinRequests: 1})
+			ctx := context.Background()
+			if tt.ctxTimeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.ctxTimeout)
+				defer cancel()
+			}
+			if _, err := c.Outbound.IPGroups.List(ctx); err == nil {
+				t.Fatal("request succeeded, want a timeout")
+			}
+			if state := c.CircuitBreaker.State(ClassManagement); state != tt.want {
+				t.Errorf("got state %s, want %s", state, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,301 @@
+package mepost
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCacheTTL caches the read operations that dashboards typically call on
+// every page load.
+var DefaultCacheTTL = map[string]time.Duration{
+	OpGetGroup:     30 * time.Second,
+	OpGetIPGroup:   time.Minute,
+	OpListIPGroups: time.Minute,
+	OpListIPs:      time.Minute,
+}
+
+// cacheInvalidates lists, for each mutation, the read operations whose cached
+// results it may change.
+var cacheInvalidates = map[string][]string{
+	OpCreateGroup:      {OpListGroups, OpGetGroup},
+	OpUpdateGroup:      {OpListGroups, OpGetGroup},
+	OpDeleteGroup:      {OpListGroups, OpGetGroup, OpListSubscribers, OpGetSubscriber},
+	OpCreateSubscriber: {OpListGroups, OpGetGroup, OpListSubscribers, OpGetSubscriber},
+	OpDeleteSubscriber: {OpListGroups, OpGetGroup, OpListSubscribers, OpGetSubscriber},
+	OpSetIPGroup:       {OpListIPs, OpGetIP, OpListIPGroups, OpGetIPGroup},
+	OpStartWarmup:      {OpListIPs, OpGetIP, OpListIPGroups, OpGetIPGroup},
+	OpCancelWarmup:     {OpListIPs, OpGetIP, OpListIPGroups, OpGetIPGroup},
+	OpCreateIPGroup:    {OpListIPGroups, OpGetIPGroup},
+}
+
+// Cache caches the results of read operations. Concurrent identical reads are
+// deduplicated into a single request, and mutations made through the same
+// client invalidate the results they may change; mutations made with Do
+// invalidate everything.
+//
+// Results are kept per credential: a Cache may be shared by clients with
+// different API keys or authenticators without one seeing another's results.
+// The credential is identified by the headers the client's Authenticator sets,
+// so authenticators that sign every request differently never hit the cache.
+// The Authenticator is therefore called for cache hits too, once per call; on a
+// miss, the request sends the headers it set rather than calling it again.
+//
+// Cached results are stored encoded and decoded into a fresh value on every
+// hit, so callers may modify them. Extension.Extra and Extension.Raw are not
+// kept for cached results. Calls served from the cache, including those that
+// shared another call's request, have Operation.Cached set; they do not run
+// Hooks or fill the ResponseMeta of CaptureResponse, and Metrics that
+// implement CacheMetrics count them with RecordCacheHit.
+type Cache struct {
+	ttl map[string]time.Duration
+	// MaxEntries bounds the number of cached results. Zero means 1000.
+	MaxEntries int
+
+	mu          sync.Mutex
+	entries     map[string]*cacheEntry
+	inflight    map[string]*cacheCall
+	generations map[string]uint64
+}
+
+type cacheEntry struct {
+	operation string
+	data      []byte
+	expires   time.Time
+	result    cacheResult
+}
+
+type cacheCall struct {
+	done   chan struct{}
+	data   []byte
+	err    error
+	result cacheResult
+}
+
+// cacheResult holds the response metadata reported for cached results.
+type cacheResult struct {
+	statusCode int
+	requestID  string
+}
+
+// serve marks op as served from the cache.
+func (r cacheResult) serve(op *Operation) {
+	op.Cached = true
+	op.StatusCode, op.RequestID = r.statusCode, r.requestID
+}
+
+// NewCache creates a Cache that keeps the results of each operation in ttl,
+// e.g. DefaultCacheTTL, for the given duration. Other operations are not cached.
+func NewCache(ttl map[string]time.Duration) *Cache {
+	copied := make(map[string]time.Duration, len(ttl))
+	for op, d := range ttl {
+		copied[op] = d
+	}
+	return &Cache{
+		ttl:         copied,
+		entries:     map[string]*cacheEntry{},
+		inflight:    map[string]*cacheCall{},
+		generations: map[string]uint64{},
+	}
+}
+
+// Invalidate discards the cached results of the given operations, or of all
+// operations if none are given.
+func (cache *Cache) Invalidate(operations ...string) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if len(operations) == 0 {
+		for _, entry := range cache.entries {
+			cache.generations[entry.operation]++
+		}
+		for op := range cache.ttl {
+			cache.generations[op]++
+		}
+		cache.entries = map[string]*cacheEntry{}
+		return
+	}
+	for _, op := range operations {
+		cache.generations[op]++
+	}
+	for key, entry := range cache.entries {
+		for _, op := range operations {
+			if entry.operation == op {
+				delete(cache.entries, key)
+				break
+			}
+		}
+	}
+}
+
+// wrap returns a Handler that serves cacheable reads from the cache and
+// invalidates cached results after mutations.
+func (cache *Cache) wrap(c *Client, next Handler) Handler {
+	return func(ctx context.Context, op *Operation, response interface{}) error {
+		if op.Method != http.MethodGet {
+			err := next(ctx, op, response)
+			if related, ok := cacheInvalidates[op.Name]; ok {
+				cache.Invalidate(related...)
+			} else if op.Name == OpDo {
+				cache.Invalidate()
+			}
+			return err
+		}
+		ttl := cache.ttl[op.Name]
+		if ttl <= 0 {
+			return next(ctx, op, response)
+		}
+		credential, err := c.credentialKey(ctx, op)
+		if err != nil {
+			// The request fails to authenticate the same way.
+			return next(ctx, op, response)
+		}
+		err = cache.get(ctx, c.codec(), credential+cacheKey(op), op, ttl, next, response)
+		op.credentials = nil
+		return err
+	}
+}
+
+func (cache *Cache) get(ctx context.Context, codec Codec, key string, op *Operation, ttl time.Duration, next Handler, response interface{}) error {
+	cache.mu.Lock()
+	if entry, ok := cache.entries[key]; ok {
+		if time.Now().Before(entry.expires) {
+			cache.mu.Unlock()
+			entry.result.serve(op)
+			return codec.Unmarshal(entry.data, response)
+		}
+		delete(cache.entries, key)
+	}
+	if call, ok := cache.inflight[key]; ok {
+		cache.mu.Unlock()
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if call.err == nil {
+			call.result.serve(op)
+			return codec.Unmarshal(call.data, response)
+		}
+		if isContextError(call.err) && ctx.Err() == nil {
+			// The leading caller gave up, but this one has not.
+			return cache.get(ctx, codec, key, op, ttl, next, response)
+		}
+		return call.err
+	}
+	call := &cacheCall{done: make(chan struct{})}
+	cache.inflight[key] = call
+	generation := cache.generations[op.Name]
+	cache.mu.Unlock()
+
+	err := next(ctx, op, response)
+	if err == nil {
+		call.data, err = codec.Marshal(response)
+	}
+	call.err = err
+	call.result = cacheResult{statusCode: op.StatusCode, requestID: op.RequestID}
+
+	cache.mu.Lock()
+	delete(cache.inflight, key)
+	// Results of reads that overlapped a mutation may be stale, so they are not stored.
+	if err == nil && cache.generations[op.Name] == generation {
+		cache.store(key, &cacheEntry{operation: op.Name, data: call.data, expires: time.Now().Add(ttl), result: call.result})
+	}
+	cache.mu.Unlock()
+	close(call.done)
+	return err
+}
+
+// store adds an entry, evicting expired entries and then the entries closest
+// to expiry if the cache is full. It must be called with cache.mu held.
+func (cache *Cache) store(key string, entry *cacheEntry) {
+	max := cache.MaxEntries
+	if max <= 0 {
+		max = 1000
+	}
+	if len(cache.entries) >= max {
+		now := time.Now()
+		for k, e := range cache.entries {
+			if !now.Before(e.expires) {
+				delete(cache.entries, k)
+			}
+		}
+	}
+	if excess := len(cache.entries) - max + 1; excess > 0 {
+		keys := make([]string, 0, len(cache.entries))
+		for k := range cache.entries {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			return cache.entries[keys[i]].expires.Before(cache.entries[keys[j]].expires)
+		})
+		for _, k := range keys[:excess] {
+			delete(cache.entries, k)
+		}
+	}
+	cache.entries[key] = entry
+}
+
+// cacheKey identifies a read by operation, URL and the extra headers set by middleware.
+func cacheKey(op *Operation) string {
+	var b strings.Builder
+	b.WriteString(op.Name)
+	b.WriteByte(0)
+	b.WriteString(op.URL)
+	writeHeader(&b, op.Header)
+	return b.String()
+}
+
+// credentialKey identifies the credentials sent with op by hashing the headers
+// that the client's Authenticator sets on a request for op, and the URL if it
+// changes it. Unless it changed the URL, the headers are kept in op for the
+// first attempt to send.
+func (c *Client) credentialKey(ctx context.Context, op *Operation) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, op.Method, op.URL, nil)
+	if err != nil {
+		return "", err
+	}
+	for key, values := range op.Header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	before := req.Header.Clone()
+	if err := c.authenticator().Authenticate(ctx, req); err != nil {
+		return "", err
+	}
+	credentials := http.Header{}
+	for key, values := range req.Header {
+		if strings.Join(values, ",") != strings.Join(before[key], ",") {
+			credentials[key] = values
+		}
+	}
+	h := sha256.New()
+	writeHeader(h, credentials)
+	if u := req.URL.String(); u != op.URL {
+		io.WriteString(h, "\x00"+u)
+	} else {
+		op.credentials = credentials
+	}
+	return string(h.Sum(nil)), nil
+}
+
+// writeHeader writes header to w in a canonical order.
+func writeHeader(w io.Writer, header http.Header) {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		io.WriteString(w, "\x00"+name+":"+strings.Join(header[name], ","))
+	}
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
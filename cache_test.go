@@ -0,0 +1,171 @@
+package mepost
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// cacheTestServer answers IP group lists with a group named after the
+// Authorization header of the request.
+func cacheTestServer(requests *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := requests.Add(1)
+		w.Header().Set("X-Request-Id", fmt.Sprint("req-", n))
+		fmt.Fprintf(w, `[{"name":%q}]`, r.Header.Get("Authorization"))
+	}))
+}
+
+func TestCacheSharedByCredentials(t *testing.T) {
+	var requests atomic.Int32
+	srv := cacheTestServer(&requests)
+	defer srv.Close()
+	cache := NewCache(DefaultCacheTTL)
+	newClient := func(key string) *Client {
+		c := NewClient(key)
+		c.BaseURL = srv.URL
+		c.Cache = cache
+		return c
+	}
+	var tenantCalls atomic.Int32
+	tenantA := CallbackAuth(func(context.Context) (Secret, error) {
+		tenantCalls.Add(1)
+		return "key-a", nil
+	})
+	clients := []struct {
+		client *Client
+		want   string
+	}{
+		{newClient("key-a"), "key-a"},
+		{newClient("key-b"), "key-b"},
+		{newClient("key-a"), "key-a"},
+		{&Client{BaseURL: srv.URL, Cache: cache, Auth: tenantA}, "key-a"},
+	}
+	ctx := context.Background()
+	for i, tt := range clients {
+		for j := 0; j < 2; j++ {
+			groups, err := tt.client.ipGroups().List(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(groups) != 1 || groups[0].Name != tt.want {
+				t.Errorf("client %d: got groups %+v, want %q", i, groups, tt.want)
+			}
+		}
+	}
+	// Clients sending the same credential share results.
+	if got := requests.Load(); got != 2 {
+		t.Errorf("got %d requests, want one per credential", got)
+	}
+	// Cache hits still call the authenticator to find the credential.
+	if got := tenantCalls.Load(); got != 2 {
+		t.Errorf("got %d authenticator calls for 2 cache hits, want 2", got)
+	}
+}
+
+type recordedMetrics struct {
+	mu         sync.Mutex
+	operations []string
+	hits       []string
+}
+
+func (m *recordedMetrics) RecordOperation(operation string, status int, err error, duration time.Duration, retries int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.operations = append(m.operations, fmt.Sprint(operation, " ", status))
+}
+
+func (m *recordedMetrics) RecordCacheHit(operation string, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hits = append(m.hits, operation)
+}
+
+func TestCacheHit(t *testing.T) {
+	var requests atomic.Int32
+	srv := cacheTestServer(&requests)
+	defer srv.Close()
+	c := NewClient("key")
+	c.BaseURL = srv.URL
+	c.Cache = NewCache(DefaultCacheTTL)
+	metrics := &recordedMetrics{}
+	c.Metrics = metrics
+	var ops []Operation
+	c.Middleware = append(c.Middleware, func(next Handler) Handler {
+		return func(ctx context.Context, op *Operation, response interface{}) error {
+			err := next(ctx, op, response)
+			ops = append(ops, *op)
+			return err
+		}
+	})
+	var hooked int
+	c.Hooks.AfterResponse = func(context.Context, *Operation, interface{}, time.Duration) { hooked++ }
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		var meta ResponseMeta
+		if _, err := c.Outbound.IPGroups.List(CaptureResponse(ctx, &meta)); err != nil {
+			t.Fatal(err)
+		}
+		if cached := i > 0; (meta.StatusCode == 0) != cached {
+			t.Errorf("call %d: got response meta %+v", i, meta)
+		}
+	}
+
+	if len(ops) != 2 || ops[0].Cached || !ops[1].Cached {
+		t.Fatalf("got operations %+v, want a request and a cache hit", ops)
+	}
+	if hit := ops[1]; hit.StatusCode != http.StatusOK || hit.RequestID != "req-1" || hit.Attempts != 0 {
+		t.Errorf("cache hit reported status %d, request ID %q and %d attempts", hit.StatusCode, hit.RequestID, hit.Attempts)
+	}
+	if hooked != 1 {
+		t.Errorf("AfterResponse called %d times, want once", hooked)
+	}
+	if fmt.Sprint(metrics.operations) != "[ListIPGroups 200]" || fmt.Sprint(metrics.hits) != "[ListIPGroups]" {
+		t.Errorf("got operations %v and cache hits %v", metrics.operations, metrics.hits)
+	}
+}
+
+func TestCacheAuthenticatorCalls(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The first request fails, so that the call is retried.
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintf(w, `[{"name":%q}]`, r.Header.Get("Authorization"))
+	}))
+	defer srv.Close()
+	var calls atomic.Int32
+	c := NewClient("")
+	c.BaseURL = srv.URL
+	c.Cache = NewCache(DefaultCacheTTL)
+	c.Retry = RetryPolicy{MaxRetries: 1, MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+	c.Auth = CallbackAuth(func(context.Context) (Secret, error) {
+		return Secret(fmt.Sprint("key-", calls.Add(1))), nil
+	})
+
+	groups, err := c.Outbound.IPGroups.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The first attempt sends the key the cache was keyed with, and the retry
+	// authenticates again.
+	if calls.Load() != 2 || groups[0].Name != "key-2" {
+		t.Fatalf("got %d authenticator calls and groups %+v after a miss, want 2 and key-2", calls.Load(), groups)
+	}
+	// A different key misses, and the request sends it without another call.
+	groups, err = c.Outbound.IPGroups.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != 3 || requests.Load() != 3 || groups[0].Name != "key-3" {
+		t.Errorf("got %d authenticator calls, %d requests and groups %+v, want 3, 3 and key-3", calls.Load(), requests.Load(), groups)
+	}
+}
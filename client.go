@@ -2,245 +2,504 @@ package mepost
 
 import (
 	"bytes"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
+	"sync/atomic"
+	"time"
 )
 
 // Client represents the client for the Mepost API.
+//
+// The API is organized by resource, e.g. client.Groups.Get or
+// client.Outbound.IPs.StartWarmup. The services are set up by NewClient; the
+// deprecated flat methods also work on clients built as struct literals.
 type Client struct {
-	APIKey  string
+	APIKey  Secret
 	BaseURL string
+
+	// Auth adds credentials to requests. If nil, APIKey is sent in the Authorization header.
+	Auth Authenticator
+
+	Groups      *GroupsService
+	Subscribers *SubscribersService
+	Messages    *MessagesService
+	Outbound    *OutboundService
+	Domains     *DomainsService
+
+	// HTTPClient is used to make requests. If nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+	// Retry controls how failed requests are retried. Clients created with
+	// NewClient do not retry until Retry.MaxRetries is set.
+	Retry RetryPolicy
+	// RateLimiter, if set, paces requests to stay within the account's limits.
+	RateLimiter *RateLimiter
+	// CircuitBreaker, if set, fails calls fast while the API is failing.
+	CircuitBreaker *CircuitBreaker
+	// Defaults fills in unset fields of outgoing messages.
+	Defaults MessageDefaults
+
+	// Middleware wraps every API call, the first being the outermost.
+	Middleware []Middleware
+	// Hooks are called during every API call.
+	Hooks Hooks
+
+	// Logger, if set, receives a record for every HTTP request.
+	Logger *slog.Logger
+	// LogOptions controls what is logged to Logger.
+	LogOptions LogOptions
+	// Tracer, if set, starts a span for every API call.
+	Tracer Tracer
+	// Metrics, if set, records every API call.
+	Metrics Metrics
+
+	// Codec encodes requests and decodes responses. If nil, JSONCodec is used.
+	Codec Codec
+	// Compression configures gzip compression of large request bodies.
+	Compression Compression
+	// StreamRequests sends request bodies as they are encoded, with chunked
+	// transfer encoding, instead of encoding them in memory and sending their
+	// length. It saves memory for large attachments, but some gateways reject
+	// chunked bodies.
+	StreamRequests bool
+	// MaxResponseSize limits the size of response bodies. Zero means
+	// DefaultMaxResponseSize and a negative value means no limit.
+	MaxResponseSize int64
+	// Cache, if set, caches the results of read operations. See NewCache.
+	Cache *Cache
+
+	// KeepRawResponse stores the undecoded response body in the Raw field of
+	// responses, and the fields the SDK does not model in their Extra fields.
+	KeepRawResponse bool
+	// StrictDecoding makes requests fail with an UnknownFieldsError when a response
+	// contains fields the SDK does not model. It is intended for contract tests.
+	StrictDecoding bool
+
+	compressionRejected atomic.Bool
 }
 
+// DefaultBaseURL is the base URL of the Mepost API.
+const DefaultBaseURL = "https://api.mepost.io/v1"
+
 // NewClient creates a new instance of MepostClient.
 func NewClient(apiKey string) *Client {
-	return &Client{
-		APIKey:  apiKey,
-		BaseURL: "https://api.mepost.io/v1",
+	c := &Client{
+		APIKey:  Secret(apiKey),
+		BaseURL: DefaultBaseURL,
+		Retry:   DefaultRetryPolicy,
+	}
+	c.Groups = &GroupsService{client: c}
+	c.Subscribers = &SubscribersService{client: c}
+	c.Messages = &MessagesService{client: c}
+	c.Outbound = &OutboundService{
+		IPs:      &IPsService{client: c},
+		IPGroups: &IPGroupsService{client: c},
 	}
+	c.Domains = &DomainsService{client: c}
+	return c
 }
 
 // AddDomain adds a domain to the Mepost account.
+//
+// Deprecated: Use client.Domains.Create instead.
 func (c *Client) AddDomain(request AddDomainRequest) (*AddDomainResponse, error) {
-	url := fmt.Sprintf("%s/company/domain/add", c.BaseURL)
-	response := &AddDomainResponse{}
-	err := c.makeRequest("POST", url, request, response)
-	return response, err
+	return c.domains().Create(context.Background(), request)
 }
 
 // RemoveDomain removes a domain from the Mepost account.
+//
+// Deprecated: Use client.Domains.Delete instead.
 func (c *Client) RemoveDomain(request RemoveDomainRequest) (*RemoveDomainResponse, error) {
-	url := fmt.Sprintf("%s/company/domain/remove", c.BaseURL)
-	response := &RemoveDomainResponse{}
-	err := c.makeRequest("DELETE", url, request, response)
-	return response, err
+	return c.domains().Delete(context.Background(), request)
 }
 
 // ListGroups retrieves a list of email groups.
+//
+// Deprecated: Use client.Groups.List instead.
 func (c *Client) ListGroups(limit, page int) (*BaseResult[EmailGroup], error) {
-	url := fmt.Sprintf("%s/groups?limit=%d&page=%d", c.BaseURL, limit, page)
-	response := &BaseResult[EmailGroup]{}
-	err := c.makeRequest("GET", url, nil, response)
-	return response, err
+	return c.groups().List(context.Background(), &ListGroupsOptions{Limit: limit, Page: page})
 }
 
 // CreateGroup creates a new email group.
+//
+// Deprecated: Use client.Groups.Create instead.
 func (c *Client) CreateGroup(request CreateNewGroupRequest) (*EmailGroup, error) {
-	url := fmt.Sprintf("%s/groups", c.BaseURL)
-	response := &EmailGroup{}
-	err := c.makeRequest("POST", url, request, response)
-	return response, err
+	return c.groups().Create(context.Background(), request)
 }
 
 // DeleteGroup deletes an email group.
+//
+// Deprecated: Use client.Groups.Delete instead.
 func (c *Client) DeleteGroup(groupId string) (bool, error) {
-	url := fmt.Sprintf("%s/groups/%s", c.BaseURL, groupId)
-	var response bool
-	err := c.makeRequest("DELETE", url, nil, &response)
-	return response, err
+	return c.groups().Delete(context.Background(), groupId)
 }
 
 // GetGroupById retrieves details of a specific email group.
+//
+// Deprecated: Use client.Groups.Get instead.
 func (c *Client) GetGroupById(groupId string) (*EmailGroupWithCounts, error) {
-	url := fmt.Sprintf("%s/groups/%s", c.BaseURL, groupId)
-	response := &EmailGroupWithCounts{}
-	err := c.makeRequest("GET", url, nil, response)
-	return response, err
+	return c.groups().Get(context.Background(), groupId)
 }
 
 // UpdateGroup updates the name of an email group.
+//
+// Deprecated: Use client.Groups.Update instead.
 func (c *Client) UpdateGroup(groupId string, request RenameGroupRequest) (bool, error) {
-	url := fmt.Sprintf("%s/groups/%s", c.BaseURL, groupId)
-	var response bool
-	err := c.makeRequest("PUT", url, request, &response)
-	return response, err
+	return c.groups().Update(context.Background(), groupId, request)
 }
 
 // ListSubscribers retrieves a list of subscribers in a group.
+//
+// Deprecated: Use client.Subscribers.List instead.
 func (c *Client) ListSubscribers(groupId string, limit, page int) (*BaseResult[Subscriber], error) {
-	url := fmt.Sprintf("%s/groups/%s/subscribers?limit=%d&page=%d", c.BaseURL, groupId, limit, page)
-	response := &BaseResult[Subscriber]{}
-	err := c.makeRequest("GET", url, nil, response)
-	return response, err
+	return c.subscribers().List(context.Background(), groupId, &ListSubscribersOptions{Limit: limit, Page: page})
 }
 
 // AddSubscriber adds a new subscriber to a group.
+//
+// Deprecated: Use client.Subscribers.Create instead.
 func (c *Client) AddSubscriber(groupId string, request CreateSubscriberRequest) (bool, error) {
-	url := fmt.Sprintf("%s/groups/%s/subscribers", c.BaseURL, groupId)
-	var response bool
-	err := c.makeRequest("POST", url, request, &response)
-	return response, err
+	return c.subscribers().Create(context.Background(), groupId, request)
 }
 
 // DeleteSubscriber removes a subscriber from a group.
+//
+// Deprecated: Use client.Subscribers.Delete instead.
 func (c *Client) DeleteSubscriber(groupId string, request DeleteSubscriberRequest) (bool, error) {
-	url := fmt.Sprintf("%s/groups/%s/subscribers", c.BaseURL, groupId)
-	var response bool
-	err := c.makeRequest("DELETE", url, request, &response)
-	return response, err
+	return c.subscribers().Delete(context.Background(), groupId, request)
 }
 
 // GetSubscriberByEmail retrieves a subscriber's details by email.
+//
+// Deprecated: Use client.Subscribers.Get instead.
 func (c *Client) GetSubscriberByEmail(groupId, email string) (*Subscriber, error) {
-	url := fmt.Sprintf("%s/groups/%s/subscribers/%s", c.BaseURL, groupId, email)
-	response := &Subscriber{}
-	err := c.makeRequest("GET", url, nil, response)
-	return response, err
+	return c.subscribers().Get(context.Background(), groupId, email)
 }
 
 // SendMarketing sends a marketing email.
+//
+// Deprecated: Use client.Messages.SendMarketing instead.
 func (c *Client) SendMarketing(request SendMarketingRequest) (*Schedule, error) {
-	url := fmt.Sprintf("%s/messages/marketing", c.BaseURL)
-	response := &Schedule{}
-	err := c.makeRequest("POST", url, request, response)
-	return response, err
+	return c.messages().SendMarketing(context.Background(), request)
 }
 
 // SendMessageByTemplate sends a message using a specified template.
+//
+// Deprecated: Use client.Messages.SendMarketingTemplate instead.
 func (c *Client) SendMessageByTemplate(request SendMessageByTemplateRequest) (*Schedule, error) {
-	url := fmt.Sprintf("%s/messages/marketing-by-template", c.BaseURL)
-	response := &Schedule{}
-	err := c.makeRequest("POST", url, request, response)
-	return response, err
+	return c.messages().SendMarketingTemplate(context.Background(), request)
 }
 
 // SendTransactional sends a transactional email.
+//
+// Deprecated: Use client.Messages.SendTransactional instead.
 func (c *Client) SendTransactional(request SendTransactionalRequest) (*Schedule, error) {
-	url := fmt.Sprintf("%s/messages/transactional", c.BaseURL)
-	response := &Schedule{}
-	err := c.makeRequest("POST", url, request, response)
-	return response, err
+	return c.messages().SendTransactional(context.Background(), request)
 }
 
 // SendTransactionalByTemplate sends a transactional email using a template.
+//
+// Deprecated: Use client.Messages.SendTransactionalTemplate instead.
 func (c *Client) SendTransactionalByTemplate(request SendMessageByTemplateRequest) (*Schedule, error) {
-	url := fmt.Sprintf("%s/messages/transactional-by-template", c.BaseURL)
-	response := &Schedule{}
-	err := c.makeRequest("POST", url, request, response)
-	return response, err
+	return c.messages().SendTransactionalTemplate(context.Background(), request)
 }
 
 // CreateIpGroup creates a new IP group.
+//
+// Deprecated: Use client.Outbound.IPGroups.Create instead.
 func (c *Client) CreateIpGroup(request CreateIpGroupRequest) (*IPGroup, error) {
-	url := fmt.Sprintf("%s/outbound/ip-group/create", c.BaseURL)
-	response := &IPGroup{}
-	err := c.makeRequest("POST", url, request, response)
-	return response, err
+	return c.ipGroups().Create(context.Background(), request)
 }
 
 // GetIpGroupInfo retrieves information about a specific IP group.
+//
+// Deprecated: Use client.Outbound.IPGroups.Get instead.
 func (c *Client) GetIpGroupInfo(name string) (*IPGroup, error) {
-	url := fmt.Sprintf("%s/outbound/ip-group/info/%s", c.BaseURL, name)
-	response := &IPGroup{}
-	err := c.makeRequest("GET", url, nil, response)
-	return response, err
+	return c.ipGroups().Get(context.Background(), name)
 }
 
 // ListIpGroups retrieves a list of IP groups.
+//
+// Deprecated: Use client.Outbound.IPGroups.List instead.
 func (c *Client) ListIpGroups() ([]IPGroup, error) {
-	url := fmt.Sprintf("%s/outbound/ip-group/list", c.BaseURL)
-	response := []IPGroup{}
-	err := c.makeRequest("GET", url, nil, &response)
-	return response, err
+	return c.ipGroups().List(context.Background())
 }
 
 // CancelWarmup cancels an IP warm-up process.
+//
+// Deprecated: Use client.Outbound.IPs.CancelWarmup instead.
 func (c *Client) CancelWarmup(request CancelWarmUpRequest) (*CancelWarmUpResponse, error) {
-	url := fmt.Sprintf("%s/outbound/ip/cancel-warmup", c.BaseURL)
-	response := &CancelWarmUpResponse{}
-	err := c.makeRequest("POST", url, request, response)
-	return response, err
+	return c.ips().CancelWarmup(context.Background(), request)
 }
 
 // GetIpInfo retrieves information about an IP address.
+//
+// Deprecated: Use client.Outbound.IPs.Get instead.
 func (c *Client) GetIpInfo(ip string) (*IpAddress, error) {
-	url := fmt.Sprintf("%s/outbound/ip/info/%s", c.BaseURL, ip)
-	response := &IpAddress{}
-	err := c.makeRequest("GET", url, nil, response)
-	return response, err
+	return c.ips().Get(context.Background(), ip)
 }
 
 // ListIps retrieves a list of IP addresses.
+//
+// Deprecated: Use client.Outbound.IPs.List instead.
 func (c *Client) ListIps() ([]IpAddress, error) {
-	url := fmt.Sprintf("%s/outbound/ip/list", c.BaseURL)
-	response := []IpAddress{}
-	err := c.makeRequest("GET", url, nil, &response)
-	return response, err
+	return c.ips().List(context.Background())
 }
 
 // SetIpGroup assigns an IP address to a group.
+//
+// Deprecated: Use client.Outbound.IPs.SetGroup instead.
 func (c *Client) SetIpGroup(request SetIpGroupRequest) (*SetIpGroupResponse, error) {
-	url := fmt.Sprintf("%s/outbound/ip/set-ip-group", c.BaseURL)
-	response := &SetIpGroupResponse{}
-	err := c.makeRequest("POST", url, request, response)
-	return response, err
+	return c.ips().SetGroup(context.Background(), request)
 }
 
 // StartWarmup starts the IP warm-up process.
+//
+// Deprecated: Use client.Outbound.IPs.StartWarmup instead.
 func (c *Client) StartWarmup(request StartWarmUpRequest) (*StartWarmUpResponse, error) {
-	url := fmt.Sprintf("%s/outbound/ip/start-warmup", c.BaseURL)
-	response := &StartWarmUpResponse{}
-	err := c.makeRequest("POST", url, request, response)
-	return response, err
+	return c.ips().StartWarmup(context.Background(), request)
+}
+
+// The deprecated methods reach the services through these accessors, so that
+// they keep working on clients that were not created with NewClient.
+
+func (c *Client) domains() *DomainsService {
+	if c.Domains != nil {
+		return c.Domains
+	}
+	return &DomainsService{client: c}
+}
+
+func (c *Client) groups() *GroupsService {
+	if c.Groups != nil {
+		return c.Groups
+	}
+	return &GroupsService{client: c}
 }
 
-// makeRequest handles the HTTP requests to the Mepost API.
-func (c *Client) makeRequest(method, url string, requestData interface{}, response interface{}) error {
-	var jsonData []byte
-	var err error
+func (c *Client) subscribers() *SubscribersService {
+	if c.Subscribers != nil {
+		return c.Subscribers
+	}
+	return &SubscribersService{client: c}
+}
+
+func (c *Client) messages() *MessagesService {
+	if c.Messages != nil {
+		return c.Messages
+	}
+	return &MessagesService{client: c}
+}
+
+func (c *Client) ips() *IPsService {
+	if c.Outbound != nil && c.Outbound.IPs != nil {
+		return c.Outbound.IPs
+	}
+	return &IPsService{client: c}
+}
+
+func (c *Client) ipGroups() *IPGroupsService {
+	if c.Outbound != nil && c.Outbound.IPGroups != nil {
+		return c.Outbound.IPGroups
+	}
+	return &IPGroupsService{client: c}
+}
+
+// makeRequest handles the HTTP requests to the Mepost API. name identifies the
+// operation to middleware and hooks.
+func (c *Client) makeRequest(ctx context.Context, name, method, url string, requestData interface{}, response interface{}) error {
+	op := &Operation{
+		Name:    name,
+		Method:  method,
+		URL:     url,
+		Request: requestData,
+		Header:  http.Header{},
+	}
+	next := c.call
+	if c.Cache != nil {
+		next = c.Cache.wrap(c, next)
+	}
+	h := c.chain(next)
+	if c.Tracer != nil || c.Metrics != nil {
+		h = c.instrument(h)
+	}
+	return h(ctx, op, response)
+}
+
+// call is the innermost Handler. It runs the hooks around the retry loop and
+// decodes the response.
+func (c *Client) call(ctx context.Context, op *Operation, response interface{}) error {
+	if hook := c.Hooks.BeforeRequest; hook != nil {
+		if err := hook(ctx, op); err != nil {
+			return err
+		}
+	}
+	start := time.Now()
+	err := c.roundTrip(ctx, op, response)
+	elapsed := time.Since(start)
+	if err != nil {
+		if hook := c.Hooks.OnError; hook != nil {
+			hook(ctx, op, err, elapsed)
+		}
+		return err
+	}
+	if hook := c.Hooks.AfterResponse; hook != nil {
+		hook(ctx, op, response, elapsed)
+	}
+	return nil
+}
 
-	if requestData != nil {
-		jsonData, err = json.Marshal(requestData)
-		if err != nil {
-			return fmt.Errorf("error marshalling request data: %v", err)
+func (c *Client) roundTrip(ctx context.Context, op *Operation, response interface{}) error {
+	for attempt := 0; ; attempt++ {
+		op.Attempts++
+		err := c.send(ctx, op, response)
+		if err == nil {
+			break
+		}
+		wait, ok := c.Retry.backoff(attempt, op.Method, err)
+		if !ok {
+			return err
+		}
+		if hook := c.Hooks.OnRetry; hook != nil {
+			hook(ctx, op, attempt+1, err, wait)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
 		}
 	}
 
-	req, err := http.NewRequest(method, url, bytes.NewBuffer(jsonData))
+	if c.StrictDecoding {
+		return checkUnknownFields(response)
+	}
+	return nil
+}
+
+// send makes a single attempt of a request and decodes the response body into
+// response. Non-2xx responses are returned as an *APIError.
+func (c *Client) send(ctx context.Context, op *Operation, response interface{}) error {
+	class := op.Class()
+	generation, err := c.CircuitBreaker.allow(class)
 	if err != nil {
-		return fmt.Errorf("error creating request: %v", err)
+		return err
 	}
-	req.Header.Set("Authorization", c.APIKey)
-	req.Header.Set("Content-Type", "application/json")
+	err = c.attempt(ctx, op, response)
+	c.CircuitBreaker.record(ctx, class, generation, err)
+	return err
+}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+// attempt paces and sends a request, checks the response status and decodes the response.
+func (c *Client) attempt(ctx context.Context, op *Operation, response interface{}) error {
+	if err := c.RateLimiter.Wait(ctx, op.Class()); err != nil {
+		return err
+	}
+	start := time.Now()
+	logBodies := c.Logger != nil && c.Logger.Enabled(ctx, slog.LevelDebug)
+	resp, compressed, err := c.exchange(ctx, op, c.compressRequests())
+	if err == nil && compressed && resp.StatusCode == http.StatusUnsupportedMediaType {
+		// The API does not accept compressed bodies, so stop compressing.
+		resp.Body.Close()
+		c.compressionRejected.Store(true)
+		// Sending the body again takes a token like any other request.
+		if err = c.RateLimiter.Wait(ctx, op.Class()); err == nil {
+			resp, _, err = c.exchange(ctx, op, false)
+		}
+	}
+	var body []byte
+	if err == nil {
+		op.StatusCode, op.RequestID = resp.StatusCode, requestID(resp.Header)
+		c.RateLimiter.observe(op.Class(), resp.StatusCode, resp.Header)
+		captureResponse(ctx, op, resp)
+		body, err = c.readResponse(resp, response, c.KeepRawResponse || logBodies)
+		resp.Body.Close()
+	}
+	if c.Logger != nil {
+		c.logAttempt(ctx, op, resp, body, err, time.Since(start))
+	}
 	if err != nil {
-		return fmt.Errorf("error making request: %v", err)
+		return err
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		if inv, ok := c.Auth.(invalidator); ok && resp.StatusCode == http.StatusUnauthorized {
+			inv.Invalidate()
+		}
+		return newAPIError(c.codec(), resp.StatusCode, resp.Header, body)
+	}
+	if ext, ok := response.(extensible); ok && c.KeepRawResponse {
+		ext.extension().Raw = body
+	}
+	return nil
+}
+
+// exchange sends the HTTP request, streaming the encoded op.Request as its body.
+// If compress is set, bodies above the compression threshold are gzipped and
+// compressed reports so. The caller must close the response body.
+func (c *Client) exchange(ctx context.Context, op *Operation, compress bool) (resp *http.Response, compressed bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, op.Method, op.URL, nil)
 	if err != nil {
-		return fmt.Errorf("error reading response body: %v", err)
+		return nil, false, fmt.Errorf("error creating request: %v", err)
+	}
+	body, err := c.encodeRequest(op.Request, compress)
+	if err != nil {
+		return nil, false, fmt.Errorf("error marshalling request data: %v", err)
+	}
+	compressed = body.gzipped
+	req.Body = body.reader()
+	switch {
+	case body.stream != nil:
+		req.GetBody = func() (io.ReadCloser, error) {
+			body, err := c.encodeRequest(op.Request, compressed)
+			return body.reader(), err
+		}
+	case body.data != nil:
+		req.ContentLength = int64(len(body.data))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body.data)), nil
+		}
+	}
+	if compressed {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	for key, values := range op.Header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if op.credentials != nil {
+		for key, values := range op.credentials {
+			req.Header[key] = values
+		}
+		op.credentials = nil
+	} else if err := c.authenticator().Authenticate(ctx, req); err != nil {
+		req.Body.Close()
+		return nil, false, fmt.Errorf("error authenticating request: %v", err)
 	}
 
-	err = json.Unmarshal(body, &response)
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err = client.Do(req)
 	if err != nil {
-		return fmt.Errorf("error unmarshalling response: %v", err)
+		var encErr *encodeError
+		if errors.As(err, &encErr) {
+			return nil, false, fmt.Errorf("error marshalling request data: %v", encErr.err)
+		}
+		return nil, false, fmt.Errorf("error making request: %w", err)
 	}
+	return resp, compressed, nil
+}
 
-	return nil
+// authenticator returns the Authenticator used for requests.
+func (c *Client) authenticator() Authenticator {
+	if c.Auth != nil {
+		return c.Auth
+	}
+	return APIKeyAuth(c.APIKey)
 }
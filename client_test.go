@@ -0,0 +1,22 @@
+package mepost
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFlatMethodsOnLiteralClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+
+	c := &Client{APIKey: "key", BaseURL: srv.URL}
+	if _, err := c.ListIps(); err != nil {
+		t.Errorf("ListIps: %v", err)
+	}
+	if _, err := c.ListIpGroups(); err != nil {
+		t.Errorf("ListIpGroups: %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package mepost
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Codec encodes request bodies and decodes response bodies as JSON. Set
+// Client.Codec to plug in a faster JSON implementation.
+//
+// The SDK's own types implement json.Marshaler and json.Unmarshaler, and Time,
+// ScheduledTime and Secret also implement encoding.TextMarshaler, so any codec
+// that honors the standard marshaler interfaces encodes them correctly.
+// Time and ScheduledTime unquote timestamps themselves, falling back to
+// encoding/json for the rare string that contains escapes.
+//
+// Request and response bodies, including API error bodies, are encoded and
+// decoded by the codec, as are the objects decoded again to find the unknown
+// fields kept in Extension. Only request and response bodies logged at debug
+// level are redacted with encoding/json.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+	NewEncoder(w io.Writer) Encoder
+	NewDecoder(r io.Reader) Decoder
+}
+
+// Encoder writes encoded values to a stream.
+type Encoder interface {
+	Encode(v interface{}) error
+}
+
+// Decoder reads and decodes values from a stream.
+type Decoder interface {
+	Decode(v interface{}) error
+}
+
+// JSONCodec is the Codec based on encoding/json. It is used when Client.Codec is nil.
+type JSONCodec struct{}
+
+// Marshal calls json.Marshal.
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal calls json.Unmarshal.
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// NewEncoder calls json.NewEncoder.
+func (JSONCodec) NewEncoder(w io.Writer) Encoder {
+	return json.NewEncoder(w)
+}
+
+// NewDecoder calls json.NewDecoder.
+func (JSONCodec) NewDecoder(r io.Reader) Decoder {
+	return json.NewDecoder(r)
+}
+
+// codec returns the Codec used for requests.
+func (c *Client) codec() Codec {
+	if c.Codec != nil {
+		return c.Codec
+	}
+	return JSONCodec{}
+}
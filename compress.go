@@ -0,0 +1,164 @@
+package mepost
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"sync"
+)
+
+// Compression configures gzip compression of request bodies.
+//
+// If the API rejects a compressed body with 415 Unsupported Media Type, the
+// request is sent again uncompressed and the client stops compressing.
+type Compression struct {
+	// Threshold is the encoded size in bytes above which request bodies are
+	// compressed. Zero disables compression.
+	Threshold int
+	// Level is the gzip compression level. Zero means gzip.DefaultCompression.
+	Level int
+}
+
+// compressRequests reports whether request bodies above the threshold are compressed.
+func (c *Client) compressRequests() bool {
+	return c.Compression.Threshold > 0 && !c.compressionRejected.Load()
+}
+
+// compressionLevel returns the gzip level of compressed request bodies.
+func (c *Client) compressionLevel() int {
+	if c.Compression.Level == 0 {
+		return gzip.DefaultCompression
+	}
+	return c.Compression.Level
+}
+
+// gzipWriters pools gzip writers by compression level, as each one allocates
+// about a megabyte of compressor state.
+var gzipWriters [gzip.BestCompression - gzip.HuffmanOnly + 1]sync.Pool
+
+// newGzipWriter returns a pooled gzip writer to w. Invalid levels use
+// gzip.DefaultCompression. The writer is returned with putGzipWriter.
+func newGzipWriter(w io.Writer, level int) *gzip.Writer {
+	if level < gzip.HuffmanOnly || level > gzip.BestCompression {
+		level = gzip.DefaultCompression
+	}
+	if gz, ok := gzipWriters[level-gzip.HuffmanOnly].Get().(*gzip.Writer); ok {
+		gz.Reset(w)
+		return gz
+	}
+	gz, _ := gzip.NewWriterLevel(w, level)
+	return gz
+}
+
+func putGzipWriter(gz *gzip.Writer, level int) {
+	if level < gzip.HuffmanOnly || level > gzip.BestCompression {
+		level = gzip.DefaultCompression
+	}
+	gz.Reset(nil)
+	gzipWriters[level-gzip.HuffmanOnly].Put(gz)
+}
+
+// gzipBytes compresses data.
+func gzipBytes(data []byte, level int) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := newGzipWriter(&buf, level)
+	defer putGzipWriter(gz, level)
+	if _, err := gz.Write(data); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// streamRequest returns a body that streams the encoding of v. When compress
+// is set, the encoding is buffered up to the compression threshold: smaller
+// bodies are sent as they are, with their length, and larger ones are gzipped
+// as they stream.
+func (c *Client) streamRequest(v interface{}, compress bool) (requestBody, error) {
+	if !compress {
+		return requestBody{stream: encodeBody(c.codec(), v)}, nil
+	}
+	pr, pw := io.Pipe()
+	w := &thresholdWriter{
+		limit:   c.Compression.Threshold,
+		level:   c.compressionLevel(),
+		pw:      pw,
+		decided: make(chan decision, 1),
+	}
+	codec := c.codec()
+	go func() {
+		if err := codec.NewEncoder(w).Encode(v); err != nil {
+			w.fail(&encodeError{err})
+			return
+		}
+		w.close()
+	}()
+	d := <-w.decided
+	switch {
+	case d.err != nil:
+		pr.Close()
+		return requestBody{}, d.err
+	case d.gzip:
+		return requestBody{stream: pr, gzipped: true}, nil
+	}
+	pr.Close()
+	return requestBody{data: d.plain}, nil
+}
+
+// decision is sent by a thresholdWriter once it knows whether the body is compressed.
+type decision struct {
+	gzip  bool
+	plain []byte
+	err   error
+}
+
+// thresholdWriter buffers writes until they would exceed limit, then switches
+// to gzipping everything to pw. The write that crosses the limit is not copied
+// into the buffer.
+type thresholdWriter struct {
+	limit   int
+	level   int
+	pw      *io.PipeWriter
+	decided chan decision
+
+	buf []byte
+	gz  *gzip.Writer
+}
+
+func (w *thresholdWriter) Write(p []byte) (int, error) {
+	if w.gz != nil {
+		return w.gz.Write(p)
+	}
+	if len(w.buf)+len(p) <= w.limit {
+		w.buf = append(w.buf, p...)
+		return len(p), nil
+	}
+	w.gz = newGzipWriter(w.pw, w.level)
+	w.decided <- decision{gzip: true}
+	buf := w.buf
+	w.buf = nil
+	if _, err := w.gz.Write(buf); err != nil {
+		return 0, err
+	}
+	return w.gz.Write(p)
+}
+
+func (w *thresholdWriter) close() {
+	if w.gz == nil {
+		w.decided <- decision{plain: w.buf}
+		w.pw.Close()
+		return
+	}
+	err := w.gz.Close()
+	putGzipWriter(w.gz, w.level)
+	w.pw.CloseWithError(err)
+}
+
+func (w *thresholdWriter) fail(err error) {
+	if w.gz == nil {
+		w.decided <- decision{err: err}
+	}
+	w.pw.CloseWithError(err)
+}
@@ -0,0 +1,172 @@
+package mepost
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// marketingRequest returns a marketing send to n recipients.
+func marketingRequest(n int) SendMarketingRequest {
+	request := SendMarketingRequest{
+		FromEmail: "news@example.com",
+		FromName:  "Example",
+		Subject:   "Our spring newsletter",
+		Html:      "<p>Hello {{name}}</p>",
+	}
+	for i := 0; i < n; i++ {
+		request.To = append(request.To, fmt.Sprintf("subscriber%d@example.com", i))
+	}
+	return request
+}
+
+// benchmarkSend runs send with compression on and off and reports the request
+// bytes written to the transport.
+func benchmarkSend(b *testing.B, send func(context.Context, *Client) error) {
+	response := []byte(`{"uuid":"s1","state":"scheduled"}`)
+	for _, threshold := range []int{0, 64 << 10} {
+		name := "uncompressed"
+		if threshold > 0 {
+			name = "compressed"
+		}
+		for _, stream := range []bool{false, true} {
+			name := name
+			if stream {
+				name += "-streamed"
+			}
+			b.Run(name, func(b *testing.B) {
+				c := benchClient(response)
+				c.Compression.Threshold = threshold
+				c.StreamRequests = stream
+				transport := c.HTTPClient.Transport.(*benchTransport)
+				ctx := context.Background()
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					if err := send(ctx, c); err != nil {
+						b.Fatal(err)
+					}
+				}
+				b.ReportMetric(float64(transport.sent)/float64(b.N), "sent-B/op")
+			})
+		}
+	}
+}
+
+func BenchmarkSendMarketingCompression(b *testing.B) {
+	request := marketingRequest(50000)
+	benchmarkSend(b, func(ctx context.Context, c *Client) error {
+		_, err := c.Messages.SendMarketing(ctx, request)
+		return err
+	})
+}
+
+func BenchmarkSendAttachmentsCompression(b *testing.B) {
+	request := attachmentRequest(3, 2<<20)
+	benchmarkSend(b, func(ctx context.Context, c *Client) error {
+		_, err := c.Messages.SendTransactional(ctx, request)
+		return err
+	})
+}
+
+// TestCompressionRejected checks that a compressed body rejected with 415 is
+// sent again uncompressed, and that the client stops compressing afterwards.
+func TestCompressionRejected(t *testing.T) {
+	for _, stream := range []bool{false, true} {
+		t.Run(fmt.Sprintf("stream=%v", stream), func(t *testing.T) {
+			var (
+				mu        sync.Mutex
+				encodings []string
+			)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				encoding := r.Header.Get("Content-Encoding")
+				mu.Lock()
+				encodings = append(encodings, encoding)
+				mu.Unlock()
+				if encoding != "" {
+					w.WriteHeader(http.StatusUnsupportedMediaType)
+					return
+				}
+				if _, err := io.ReadAll(r.Body); err != nil {
+					t.Errorf("reading body: %v", err)
+				}
+				w.Write([]byte(`{"uuid":"s1"}`))
+			}))
+			defer srv.Close()
+			c := NewClient("key")
+			c.BaseURL = srv.URL
+			c.Compression.Threshold = 1 << 10
+			c.StreamRequests = stream
+			ctx := context.Background()
+			request := marketingRequest(1000)
+
+			for i := 0; i < 2; i++ {
+				schedule, err := c.Messages.SendMarketing(ctx, request)
+				if err != nil {
+					t.Fatalf("send %d: %v", i, err)
+				}
+				if schedule.UUID != "s1" {
+					t.Errorf("send %d: got schedule %+v", i, schedule)
+				}
+			}
+			want := []string{"gzip", "", ""}
+			if fmt.Sprint(encodings) != fmt.Sprint(want) {
+				t.Errorf("got encodings %q, want %q", encodings, want)
+			}
+		})
+	}
+}
+
+func TestCompressionThreshold(t *testing.T) {
+	var got struct {
+		encoding string
+		to       int
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got.encoding = r.Header.Get("Content-Encoding")
+		var body io.Reader = r.Body
+		if got.encoding == "gzip" {
+			zr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				t.Errorf("reading gzipped body: %v", err)
+				return
+			}
+			body = zr
+		}
+		var request SendMarketingRequest
+		if err := (JSONCodec{}).NewDecoder(body).Decode(&request); err != nil {
+			t.Errorf("decoding body: %v", err)
+		}
+		got.to = len(request.To)
+		w.Write([]byte(`{"uuid":"s1"}`))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		recipients int
+		stream     bool
+		encoding   string
+	}{
+		{10, false, ""},
+		{10, true, ""},
+		{1000, false, "gzip"},
+		{1000, true, "gzip"},
+	}
+	for _, tt := range tests {
+		c := NewClient("key")
+		c.BaseURL = srv.URL
+		c.Compression.Threshold = 1 << 10
+		c.StreamRequests = tt.stream
+		if _, err := c.Messages.SendMarketing(context.Background(), marketingRequest(tt.recipients)); err != nil {
+			t.Fatal(err)
+		}
+		if got.encoding != tt.encoding || got.to != tt.recipients {
+			t.Errorf("%d recipients, stream=%v: got encoding %q and %d recipients, want %q",
+				tt.recipients, tt.stream, got.encoding, got.to, tt.encoding)
+		}
+	}
+}
@@ -0,0 +1,352 @@
+package mepost
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Environment variables read by LoadConfig. They take precedence over the profiles file.
+const (
+	EnvAPIKey     = "MEPOST_API_KEY"
+	EnvBaseURL    = "MEPOST_BASE_URL"
+	EnvProfile    = "MEPOST_PROFILE"
+	EnvConfigFile = "MEPOST_CONFIG_FILE"
+	EnvIpGroup    = "MEPOST_IP_GROUP"
+	EnvFromEmail  = "MEPOST_FROM_EMAIL"
+	EnvFromName   = "MEPOST_FROM_NAME"
+	EnvTimeout    = "MEPOST_TIMEOUT"
+	EnvMaxRetries = "MEPOST_MAX_RETRIES"
+	EnvMinBackoff = "MEPOST_RETRY_MIN_BACKOFF"
+	EnvMaxBackoff = "MEPOST_RETRY_MAX_BACKOFF"
+)
+
+// DefaultProfile is the profile used when none is selected.
+const DefaultProfile = "default"
+
+// Config holds the settings used to create a client.
+type Config struct {
+	// Profile is the name of the profile the settings were loaded from.
+	Profile string
+
+	APIKey  Secret
+	BaseURL string
+	// Timeout bounds each HTTP request, including reading the response. Zero means no timeout.
+	Timeout  time.Duration
+	Retry    RetryPolicy
+	Defaults MessageDefaults
+
+	// sources maps the environment variable of each setting that LoadConfig
+	// took from the profiles file to its key there, so errors name the key.
+	sources map[string]string
+}
+
+// setting names the source of a setting for errors: its profile file key if it
+// was loaded from one, its environment variable otherwise.
+func (cfg *Config) setting(env string) string {
+	if key, ok := cfg.sources[env]; ok {
+		return key
+	}
+	return env
+}
+
+// ConfigError reports a missing or invalid setting.
+type ConfigError struct {
+	// Setting names the setting, as an environment variable or a profile file key.
+	Setting string
+	Err     error
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("mepost: invalid configuration: %s: %v", e.Setting, e.Err)
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// ErrMissingSetting is wrapped by ConfigError when a required setting is not set.
+var ErrMissingSetting = errors.New("required setting is not set")
+
+// profileFile is the format of the profiles file:
+//
+//	{
+//	  "defaultProfile": "staging",
+//	  "profiles": {
+//	    "staging": {
+//	      "apiKey": "...",
+//	      "baseUrl": "https://api.mepost.io/v1",
+//	      "ipGroup": "staging-pool",
+//	      "fromEmail": "noreply@staging.example.com",
+//	      "fromName": "Example",
+//	      "timeout": "30s",
+//	      "retry": {"maxRetries": 3, "minBackoff": "1s", "maxBackoff": "20s"}
+//	    }
+//	  }
+//	}
+type profileFile struct {
+	DefaultProfile string                 `json:"defaultProfile"`
+	Profiles       map[string]profileJSON `json:"profiles"`
+}
+
+type profileJSON struct {
+	APIKey    string `json:"apiKey"`
+	BaseURL   string `json:"baseUrl"`
+	IpGroup   string `json:"ipGroup"`
+	FromEmail string `json:"fromEmail"`
+	FromName  string `json:"fromName"`
+	Timeout   string `json:"timeout"`
+	Retry     *struct {
+		MaxRetries *int   `json:"maxRetries"`
+		MinBackoff string `json:"minBackoff"`
+		MaxBackoff string `json:"maxBackoff"`
+	} `json:"retry"`
+}
+
+// DefaultConfigFile returns the default location of the profiles file, ~/.mepost/config.json.
+func DefaultConfigFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".mepost", "config.json")
+}
+
+// LoadConfig loads the settings of the named profile from the profiles file and
+// then applies the MEPOST_* environment variables on top.
+//
+// If profile is empty, MEPOST_PROFILE, the file's defaultProfile or "default" is
+// used, in that order. The profiles file is read from MEPOST_CONFIG_FILE or, if
+// that is not set, from DefaultConfigFile when it exists. The returned config is validated.
+func LoadConfig(profile string) (*Config, error) {
+	cfg := &Config{
+		BaseURL: DefaultBaseURL,
+		Retry:   DefaultRetryPolicy,
+	}
+
+	explicit := profile != ""
+	if profile == "" {
+		profile = os.Getenv(EnvProfile)
+		explicit = profile != ""
+	}
+
+	path := os.Getenv(EnvConfigFile)
+	required := path != ""
+	if path == "" {
+		path = DefaultConfigFile()
+	}
+	if path != "" {
+		file, err := readProfileFile(path, required)
+		if err != nil {
+			return nil, err
+		}
+		if file != nil {
+			if profile == "" {
+				profile = file.DefaultProfile
+			}
+			if profile == "" {
+				profile = DefaultProfile
+			}
+			p, ok := file.Profiles[profile]
+			if !ok && (explicit || file.DefaultProfile != "") {
+				return nil, &ConfigError{Setting: EnvProfile, Err: fmt.Errorf("profile %q not found in %s", profile, path)}
+			}
+			if ok {
+				if err := p.applyTo(cfg, profile); err != nil {
+					return nil, err
+				}
+			}
+		} else if explicit {
+			return nil, &ConfigError{Setting: EnvProfile, Err: fmt.Errorf("profile %q selected but no profiles file found", profile)}
+		}
+	}
+	if profile == "" {
+		profile = DefaultProfile
+	}
+	cfg.Profile = profile
+
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// NewClientFromEnv creates a client from LoadConfig("").
+func NewClientFromEnv() (*Client, error) {
+	cfg, err := LoadConfig("")
+	if err != nil {
+		return nil, err
+	}
+	return cfg.NewClient()
+}
+
+// Validate checks that the config holds every required setting and that all settings are valid.
+func (cfg *Config) Validate() error {
+	if cfg.APIKey == "" {
+		return &ConfigError{Setting: EnvAPIKey, Err: ErrMissingSetting}
+	}
+	if cfg.BaseURL == "" {
+		return &ConfigError{Setting: cfg.setting(EnvBaseURL), Err: ErrMissingSetting}
+	}
+	u, err := url.Parse(cfg.BaseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return &ConfigError{Setting: cfg.setting(EnvBaseURL), Err: fmt.Errorf("%q is not an absolute http(s) URL", cfg.BaseURL)}
+	}
+	if cfg.Defaults.FromEmail != "" && !strings.Contains(cfg.Defaults.FromEmail, "@") {
+		return &ConfigError{Setting: cfg.setting(EnvFromEmail), Err: fmt.Errorf("%q is not an email address", cfg.Defaults.FromEmail)}
+	}
+	if cfg.Timeout < 0 {
+		return &ConfigError{Setting: cfg.setting(EnvTimeout), Err: errors.New("must not be negative")}
+	}
+	if cfg.Retry.MaxRetries < 0 {
+		return &ConfigError{Setting: cfg.setting(EnvMaxRetries), Err: errors.New("must not be negative")}
+	}
+	if cfg.Retry.MinBackoff < 0 {
+		return &ConfigError{Setting: cfg.setting(EnvMinBackoff), Err: errors.New("must not be negative")}
+	}
+	if cfg.Retry.MaxBackoff < cfg.Retry.MinBackoff {
+		return &ConfigError{Setting: cfg.setting(EnvMaxBackoff), Err: errors.New("must not be less than the minimum backoff")}
+	}
+	return nil
+}
+
+// NewClient validates the config and creates a client from it.
+func (cfg *Config) NewClient() (*Client, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	c := NewClient(cfg.APIKey.Value())
+	c.BaseURL = cfg.BaseURL
+	c.Retry = cfg.Retry
+	c.Defaults = cfg.Defaults
+	if cfg.Timeout > 0 {
+		c.HTTPClient = &http.Client{Timeout: cfg.Timeout}
+	}
+	return c, nil
+}
+
+func readProfileFile(path string, required bool) (*profileFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !required && errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, &ConfigError{Setting: EnvConfigFile, Err: err}
+	}
+	file := &profileFile{}
+	if err := json.Unmarshal(data, file); err != nil {
+		return nil, &ConfigError{Setting: EnvConfigFile, Err: fmt.Errorf("error parsing %s: %v", path, err)}
+	}
+	return file, nil
+}
+
+func (p profileJSON) applyTo(cfg *Config, name string) error {
+	setting := func(key string) string {
+		return fmt.Sprintf("profiles.%s.%s", name, key)
+	}
+	if cfg.sources == nil {
+		cfg.sources = make(map[string]string)
+	}
+	// from records that the setting of env was taken from key, if value is set.
+	from := func(value, env, key string) bool {
+		if value != "" {
+			cfg.sources[env] = setting(key)
+		}
+		return value != ""
+	}
+	if from(p.APIKey, EnvAPIKey, "apiKey") {
+		cfg.APIKey = Secret(p.APIKey)
+	}
+	if from(p.BaseURL, EnvBaseURL, "baseUrl") {
+		cfg.BaseURL = p.BaseURL
+	}
+	if from(p.IpGroup, EnvIpGroup, "ipGroup") {
+		cfg.Defaults.IpGroup = p.IpGroup
+	}
+	if from(p.FromEmail, EnvFromEmail, "fromEmail") {
+		cfg.Defaults.FromEmail = p.FromEmail
+	}
+	if from(p.FromName, EnvFromName, "fromName") {
+		cfg.Defaults.FromName = p.FromName
+	}
+	from(p.Timeout, EnvTimeout, "timeout")
+	if err := parseDurationSetting(p.Timeout, setting("timeout"), &cfg.Timeout); err != nil {
+		return err
+	}
+	if p.Retry != nil {
+		if p.Retry.MaxRetries != nil {
+			cfg.sources[EnvMaxRetries] = setting("retry.maxRetries")
+			cfg.Retry.MaxRetries = *p.Retry.MaxRetries
+		}
+		from(p.Retry.MinBackoff, EnvMinBackoff, "retry.minBackoff")
+		if err := parseDurationSetting(p.Retry.MinBackoff, setting("retry.minBackoff"), &cfg.Retry.MinBackoff); err != nil {
+			return err
+		}
+		from(p.Retry.MaxBackoff, EnvMaxBackoff, "retry.maxBackoff")
+		if err := parseDurationSetting(p.Retry.MaxBackoff, setting("retry.maxBackoff"), &cfg.Retry.MaxBackoff); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (cfg *Config) applyEnv() error {
+	// Settings taken from the environment override those of the profile.
+	for env := range cfg.sources {
+		if os.Getenv(env) != "" {
+			delete(cfg.sources, env)
+		}
+	}
+	if v := os.Getenv(EnvAPIKey); v != "" {
+		cfg.APIKey = Secret(v)
+	}
+	if v := os.Getenv(EnvBaseURL); v != "" {
+		cfg.BaseURL = v
+	}
+	if v := os.Getenv(EnvIpGroup); v != "" {
+		cfg.Defaults.IpGroup = v
+	}
+	if v := os.Getenv(EnvFromEmail); v != "" {
+		cfg.Defaults.FromEmail = v
+	}
+	if v := os.Getenv(EnvFromName); v != "" {
+		cfg.Defaults.FromName = v
+	}
+	if err := parseDurationSetting(os.Getenv(EnvTimeout), EnvTimeout, &cfg.Timeout); err != nil {
+		return err
+	}
+	if v := os.Getenv(EnvMaxRetries); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return &ConfigError{Setting: EnvMaxRetries, Err: err}
+		}
+		cfg.Retry.MaxRetries = n
+	}
+	if err := parseDurationSetting(os.Getenv(EnvMinBackoff), EnvMinBackoff, &cfg.Retry.MinBackoff); err != nil {
+		return err
+	}
+	return parseDurationSetting(os.Getenv(EnvMaxBackoff), EnvMaxBackoff, &cfg.Retry.MaxBackoff)
+}
+
+// parseDurationSetting parses value into d if it is set.
+func parseDurationSetting(value, setting string, d *time.Duration) error {
+	if value == "" {
+		return nil
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return &ConfigError{Setting: setting, Err: err}
+	}
+	*d = parsed
+	return nil
+}
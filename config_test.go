@@ -0,0 +1,181 @@
+package mepost
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// configEnv clears the MEPOST_* variables and points LoadConfig at a profiles
+// file holding profiles.
+func configEnv(t *testing.T, profiles string) {
+	t.Helper()
+	for _, env := range []string{
+		EnvAPIKey, EnvBaseURL, EnvProfile, EnvConfigFile, EnvIpGroup, EnvFromEmail,
+		EnvFromName, EnvTimeout, EnvMaxRetries, EnvMinBackoff, EnvMaxBackoff,
+	} {
+		t.Setenv(env, "")
+	}
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte(profiles), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(EnvConfigFile, path)
+}
+
+const testProfiles = `{
+  "defaultProfile": "staging",
+  "profiles": {
+    "staging": {
+      "apiKey": "staging-key",
+      "baseUrl": "https://staging.example.com/v1",
+      "fromEmail": "noreply@staging.example.com",
+      "timeout": "30s",
+      "retry": {"maxRetries": 3, "minBackoff": "1s", "maxBackoff": "20s"}
+    },
+    "broken": {
+      "apiKey": "key",
+      "baseUrl": "staging.example.com",
+      "fromEmail": "noreply",
+      "retry": {"maxRetries": -1, "minBackoff": "5s", "maxBackoff": "1s"}
+    }
+  }
+}`
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want Config
+	}{
+		{
+			name: "profile",
+			want: Config{
+				Profile:  "staging",
+				APIKey:   "staging-key",
+				BaseURL:  "https://staging.example.com/v1",
+				Timeout:  30 * time.Second,
+				Retry:    RetryPolicy{MaxRetries: 3, MinBackoff: time.Second, MaxBackoff: 20 * time.Second},
+				Defaults: MessageDefaults{FromEmail: "noreply@staging.example.com"},
+			},
+		},
+		{
+			name: "environment over profile",
+			env: map[string]string{
+				EnvAPIKey:     "env-key",
+				EnvBaseURL:    "https://env.example.com",
+				EnvTimeout:    "5s",
+				EnvMaxRetries: "1",
+				EnvFromName:   "Env",
+			},
+			want: Config{
+				Profile:  "staging",
+				APIKey:   "env-key",
+				BaseURL:  "https://env.example.com",
+				Timeout:  5 * time.Second,
+				Retry:    RetryPolicy{MaxRetries: 1, MinBackoff: time.Second, MaxBackoff: 20 * time.Second},
+				Defaults: MessageDefaults{FromEmail: "noreply@staging.example.com", FromName: "Env"},
+			},
+		},
+		{
+			name: "environment only",
+			env:  map[string]string{EnvConfigFile: "", EnvAPIKey: "env-key"},
+			want: Config{
+				Profile: DefaultProfile,
+				APIKey:  "env-key",
+				BaseURL: DefaultBaseURL,
+				Retry:   DefaultRetryPolicy,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configEnv(t, testProfiles)
+			for env, v := range tt.env {
+				t.Setenv(env, v)
+			}
+			cfg, err := LoadConfig("")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := *cfg
+			got.sources = nil
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got config %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile string
+		env     map[string]string
+		setting string
+	}{
+		{"missing key", "", map[string]string{EnvConfigFile: ""}, EnvAPIKey},
+		{"base URL from profile", "broken", nil, "profiles.broken.baseUrl"},
+		{"base URL from environment", "broken", map[string]string{EnvBaseURL: "example.com"}, EnvBaseURL},
+		{"from email from profile", "broken", map[string]string{EnvBaseURL: "https://example.com"}, "profiles.broken.fromEmail"},
+		{"from email from environment", "staging", map[string]string{EnvFromEmail: "nobody"}, EnvFromEmail},
+		{"max retries from profile", "broken", map[string]string{EnvBaseURL: "https://example.com", EnvFromEmail: "a@example.com"}, "profiles.broken.retry.maxRetries"},
+		{"backoff from profile", "broken", map[string]string{EnvBaseURL: "https://example.com", EnvFromEmail: "a@example.com", EnvMaxRetries: "0"}, "profiles.broken.retry.maxBackoff"},
+		{"negative timeout", "staging", map[string]string{EnvTimeout: "-1s"}, EnvTimeout},
+		{"unparsable retries", "staging", map[string]string{EnvMaxRetries: "many"}, EnvMaxRetries},
+		{"unknown profile", "production", nil, EnvProfile},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configEnv(t, testProfiles)
+			for env, v := range tt.env {
+				t.Setenv(env, v)
+			}
+			_, err := LoadConfig(tt.profile)
+			var cfgErr *ConfigError
+			if !errors.As(err, &cfgErr) {
+				t.Fatalf("got error %v, want a ConfigError", err)
+			}
+			if cfgErr.Setting != tt.setting {
+				t.Errorf("got error for %s (%v), want %s", cfgErr.Setting, err, tt.setting)
+			}
+		})
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	valid := Config{APIKey: "key", BaseURL: DefaultBaseURL, Retry: DefaultRetryPolicy}
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		setting string
+		missing bool
+	}{
+		{"valid", func(*Config) {}, "", false},
+		{"no key", func(c *Config) { c.APIKey = "" }, EnvAPIKey, true},
+		{"no base URL", func(c *Config) { c.BaseURL = "" }, EnvBaseURL, true},
+		{"relative base URL", func(c *Config) { c.BaseURL = "/v1" }, EnvBaseURL, false},
+		{"bad from email", func(c *Config) { c.Defaults.FromEmail = "noreply" }, EnvFromEmail, false},
+		{"negative min backoff", func(c *Config) { c.Retry.MinBackoff = -time.Second }, EnvMinBackoff, false},
+	}
+	for _, tt := range tests {
+		cfg := valid
+		tt.modify(&cfg)
+		err := cfg.Validate()
+		if tt.setting == "" {
+			if err != nil {
+				t.Errorf("%s: got error %v", tt.name, err)
+			}
+			continue
+		}
+		var cfgErr *ConfigError
+		if !errors.As(err, &cfgErr) || cfgErr.Setting != tt.setting || errors.Is(err, ErrMissingSetting) != tt.missing {
+			t.Errorf("%s: got error %v, want one for %s", tt.name, err, tt.setting)
+		}
+	}
+}
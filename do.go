@@ -0,0 +1,27 @@
+package mepost
+
+import (
+	"context"
+	"net/url"
+	"strings"
+)
+
+// Do sends a request to an endpoint the SDK does not wrap yet and decodes the
+// response into a value of type T. path is relative to the client's base URL,
+// e.g. "/messages/info"; query and body may be nil. The request goes through the
+// same pipeline as the built-in methods.
+//
+// Like every method of the client, Do returns an *APIError for non-2xx
+// responses and leaves the result zero. Releases before Do was added decoded
+// error bodies into the result and returned a nil error instead.
+//
+//	info, err := mepost.Do[mepost.GetMessageInfoResponse](ctx, client, "GET", "/messages/info", query, nil)
+func Do[T any](ctx context.Context, c *Client, method, path string, query url.Values, body interface{}) (T, error) {
+	var result T
+	u := strings.TrimRight(c.BaseURL, "/") + "/" + strings.TrimPrefix(path, "/")
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	err := c.makeRequest(ctx, OpDo, method, u, body, &result)
+	return result, err
+}
@@ -0,0 +1,69 @@
+package mepost
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestDo(t *testing.T) {
+	type info struct {
+		Extension
+		Email  string `json:"email"`
+		Opened int    `json:"opened"`
+	}
+	var got struct {
+		method, path, query, body, auth string
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got.method, got.path, got.query, got.body = r.Method, r.URL.Path, r.URL.RawQuery, string(body)
+		got.auth = r.Header.Get("Authorization")
+		switch r.URL.Path {
+		case "/v1/messages/info":
+			w.Write([]byte(`{"email":"alice@example.com","opened":2}`))
+		case "/v1/messages/cancel":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"success":false,"errors":[{"code":404,"message":"message not found"}]}`))
+		}
+	}))
+	defer srv.Close()
+	c := NewClient("key")
+	c.BaseURL = srv.URL + "/v1/"
+	ctx := context.Background()
+
+	result, err := Do[info](ctx, c, http.MethodGet, "/messages/info", url.Values{"email": {"alice@example.com"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Email != "alice@example.com" || result.Opened != 2 {
+		t.Errorf("got result %+v", result)
+	}
+	if got.method != "GET" || got.path != "/v1/messages/info" || got.query != "email=alice%40example.com" || got.auth != "key" {
+		t.Errorf("got request %+v", got)
+	}
+
+	// Responses without a body leave the result zero.
+	ok, err := Do[*bool](ctx, c, http.MethodPost, "messages/cancel", nil, CancelScheduledMessageRequest{})
+	if err != nil || ok != nil {
+		t.Errorf("got result %v and error %v for 204 No Content", ok, err)
+	}
+	if got.method != "POST" || got.body == "" {
+		t.Errorf("got request %+v, want the encoded body", got)
+	}
+
+	result, err = Do[info](ctx, c, http.MethodGet, "/messages/unknown", nil, nil)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound || len(apiErr.Errors) != 1 || apiErr.Errors[0].Message != "message not found" {
+		t.Fatalf("got error %v, want an APIError with the API's errors", err)
+	}
+	if result.Email != "" {
+		t.Errorf("got result %+v for an error response, want it zero", result)
+	}
+}
@@ -0,0 +1,30 @@
+package mepost
+
+import "context"
+
+// DomainsService manages the sending domains of the account.
+type DomainsService struct {
+	client *Client
+}
+
+// Create adds a domain to the account and returns the DNS records to publish.
+func (s *DomainsService) Create(ctx context.Context, request AddDomainRequest) (*AddDomainResponse, error) {
+	url, err := s.client.buildURL(nil, "company", "domain", "add")
+	if err != nil {
+		return nil, err
+	}
+	response := &AddDomainResponse{}
+	err = s.client.makeRequest(ctx, OpCreateDomain, "POST", url, request, response)
+	return response, err
+}
+
+// Delete removes a domain from the account.
+func (s *DomainsService) Delete(ctx context.Context, request RemoveDomainRequest) (*RemoveDomainResponse, error) {
+	url, err := s.client.buildURL(nil, "company", "domain", "remove")
+	if err != nil {
+		return nil, err
+	}
+	response := &RemoveDomainResponse{}
+	err = s.client.makeRequest(ctx, OpDeleteDomain, "DELETE", url, request, response)
+	return response, err
+}
@@ -0,0 +1,213 @@
+package mepost
+
+import "strings"
+
+// The enum types below are string based, so values the SDK does not know about
+// survive decoding and re-encoding unchanged. Comparisons made by the helper
+// methods are case-insensitive.
+
+// JobStatus represents the status of a scheduled job.
+type JobStatus string
+
+// Known job statuses.
+const (
+	JobStatusPending    JobStatus = "pending"
+	JobStatusScheduled  JobStatus = "scheduled"
+	JobStatusProcessing JobStatus = "processing"
+	JobStatusCompleted  JobStatus = "completed"
+	JobStatusFailed     JobStatus = "failed"
+	JobStatusCancelled  JobStatus = "cancelled"
+)
+
+// String returns the raw status value.
+func (s JobStatus) String() string {
+	return string(s)
+}
+
+// IsKnown reports whether the status is one of the statuses defined by the SDK.
+func (s JobStatus) IsKnown() bool {
+	switch s.normalize() {
+	case JobStatusPending, JobStatusScheduled, JobStatusProcessing,
+		JobStatusCompleted, JobStatusFailed, JobStatusCancelled:
+		return true
+	}
+	return false
+}
+
+// IsTerminal reports whether the job has finished and its status will not change again.
+func (s JobStatus) IsTerminal() bool {
+	switch s.normalize() {
+	case JobStatusCompleted, JobStatusFailed, JobStatusCancelled:
+		return true
+	}
+	return false
+}
+
+// IsFailure reports whether the job finished without sending.
+func (s JobStatus) IsFailure() bool {
+	return s.normalize() == JobStatusFailed
+}
+
+func (s JobStatus) normalize() JobStatus {
+	return JobStatus(strings.ToLower(string(s)))
+}
+
+// JobType represents the kind of a scheduled job.
+type JobType string
+
+// Known job types.
+const (
+	JobTypeTransactional JobType = "transactional"
+	JobTypeMarketing     JobType = "marketing"
+)
+
+// String returns the raw type value.
+func (t JobType) String() string {
+	return string(t)
+}
+
+// IsKnown reports whether the type is one of the types defined by the SDK.
+func (t JobType) IsKnown() bool {
+	switch JobType(strings.ToLower(string(t))) {
+	case JobTypeTransactional, JobTypeMarketing:
+		return true
+	}
+	return false
+}
+
+// ScheduleState represents the delivery state of a schedule.
+type ScheduleState string
+
+// Known schedule states.
+const (
+	ScheduleStatePending   ScheduleState = "pending"
+	ScheduleStateSending   ScheduleState = "sending"
+	ScheduleStateSent      ScheduleState = "sent"
+	ScheduleStateFailed    ScheduleState = "failed"
+	ScheduleStateCancelled ScheduleState = "cancelled"
+)
+
+// String returns the raw state value.
+func (s ScheduleState) String() string {
+	return string(s)
+}
+
+// IsKnown reports whether the state is one of the states defined by the SDK.
+func (s ScheduleState) IsKnown() bool {
+	switch s.normalize() {
+	case ScheduleStatePending, ScheduleStateSending, ScheduleStateSent,
+		ScheduleStateFailed, ScheduleStateCancelled:
+		return true
+	}
+	return false
+}
+
+// IsTerminal reports whether the schedule has finished and its state will not change again.
+func (s ScheduleState) IsTerminal() bool {
+	switch s.normalize() {
+	case ScheduleStateSent, ScheduleStateFailed, ScheduleStateCancelled:
+		return true
+	}
+	return false
+}
+
+// IsFailure reports whether the schedule finished without sending.
+func (s ScheduleState) IsFailure() bool {
+	return s.normalize() == ScheduleStateFailed
+}
+
+func (s ScheduleState) normalize() ScheduleState {
+	return ScheduleState(strings.ToLower(string(s)))
+}
+
+// EventType represents the type of an email transaction event.
+type EventType string
+
+// Known event types.
+const (
+	EventTypeRead        EventType = "read"
+	EventTypeClick       EventType = "click"
+	EventTypeHardBounce  EventType = "hard_bounce"
+	EventTypeSoftBounce  EventType = "soft_bounce"
+	EventTypeUnsubscribe EventType = "unsubscribe"
+)
+
+// String returns the raw event type value.
+func (e EventType) String() string {
+	return string(e)
+}
+
+// IsKnown reports whether the event type is one of the types defined by the SDK.
+func (e EventType) IsKnown() bool {
+	switch e.normalize() {
+	case EventTypeRead, EventTypeClick, EventTypeHardBounce,
+		EventTypeSoftBounce, EventTypeUnsubscribe:
+		return true
+	}
+	return false
+}
+
+// IsTerminal reports whether no further events are expected for the recipient.
+func (e EventType) IsTerminal() bool {
+	switch e.normalize() {
+	case EventTypeHardBounce, EventTypeUnsubscribe:
+		return true
+	}
+	return false
+}
+
+// IsFailure reports whether the event is a delivery failure.
+func (e EventType) IsFailure() bool {
+	switch e.normalize() {
+	case EventTypeHardBounce, EventTypeSoftBounce:
+		return true
+	}
+	return false
+}
+
+func (e EventType) normalize() EventType {
+	return EventType(strings.ToLower(string(e)))
+}
+
+// IPStatus represents the status of an outbound IP address.
+type IPStatus string
+
+// Known IP statuses.
+const (
+	IPStatusActive    IPStatus = "active"
+	IPStatusInactive  IPStatus = "inactive"
+	IPStatusWarmingUp IPStatus = "warming_up"
+	IPStatusSuspended IPStatus = "suspended"
+)
+
+// String returns the raw status value.
+func (s IPStatus) String() string {
+	return string(s)
+}
+
+// IsKnown reports whether the status is one of the statuses defined by the SDK.
+func (s IPStatus) IsKnown() bool {
+	switch s.normalize() {
+	case IPStatusActive, IPStatusInactive, IPStatusWarmingUp, IPStatusSuspended:
+		return true
+	}
+	return false
+}
+
+// IsTerminal reports whether the IP has settled, i.e. it is not warming up.
+func (s IPStatus) IsTerminal() bool {
+	switch s.normalize() {
+	case IPStatusActive, IPStatusInactive, IPStatusSuspended:
+		return true
+	}
+	return false
+}
+
+// IsFailure reports whether the IP cannot be used for sending.
+func (s IPStatus) IsFailure() bool {
+	return s.normalize() == IPStatusSuspended
+}
+
+func (s IPStatus) normalize() IPStatus {
+	return IPStatus(strings.ToLower(string(s)))
+}
@@ -0,0 +1,145 @@
+package mepost
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// enumCase is the expected classification of a value. Values are checked as
+// given and in upper case.
+type enumCase struct {
+	value                    string
+	known, terminal, failure bool
+}
+
+// lifecycle is implemented by the enums with terminal and failure values.
+type lifecycle interface {
+	IsKnown() bool
+	IsTerminal() bool
+	IsFailure() bool
+}
+
+func checkEnum(t *testing.T, name string, tests []enumCase, parse func(string) lifecycle) {
+	t.Helper()
+	for _, tt := range tests {
+		for _, value := range []string{tt.value, strings.ToUpper(tt.value)} {
+			v := parse(value)
+			if v.IsKnown() != tt.known || v.IsTerminal() != tt.terminal || v.IsFailure() != tt.failure {
+				t.Errorf("%s(%q): got known %v, terminal %v, failure %v; want %v, %v, %v", name, value,
+					v.IsKnown(), v.IsTerminal(), v.IsFailure(), tt.known, tt.terminal, tt.failure)
+			}
+		}
+	}
+}
+
+func TestJobStatus(t *testing.T) {
+	checkEnum(t, "JobStatus", []enumCase{
+		{"pending", true, false, false},
+		{"scheduled", true, false, false},
+		{"processing", true, false, false},
+		{"completed", true, true, false},
+		{"failed", true, true, true},
+		{"cancelled", true, true, false},
+		{"canceled", false, false, false},
+		{"paused", false, false, false},
+		{"", false, false, false},
+	}, func(s string) lifecycle { return JobStatus(s) })
+}
+
+func TestJobType(t *testing.T) {
+	tests := []struct {
+		value string
+		known bool
+	}{
+		{"transactional", true},
+		{"Marketing", true},
+		{"MARKETING", true},
+		{"automation", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := JobType(tt.value).IsKnown(); got != tt.known {
+			t.Errorf("JobType(%q).IsKnown() = %v, want %v", tt.value, got, tt.known)
+		}
+	}
+}
+
+func TestScheduleState(t *testing.T) {
+	checkEnum(t, "ScheduleState", []enumCase{
+		{"pending", true, false, false},
+		{"sending", true, false, false},
+		{"sent", true, true, false},
+		{"failed", true, true, true},
+		{"cancelled", true, true, false},
+		{"queued", false, false, false},
+		{"", false, false, false},
+	}, func(s string) lifecycle { return ScheduleState(s) })
+}
+
+func TestEventType(t *testing.T) {
+	checkEnum(t, "EventType", []enumCase{
+		{"read", true, false, false},
+		{"click", true, false, false},
+		{"hard_bounce", true, true, true},
+		{"soft_bounce", true, false, true},
+		{"unsubscribe", true, true, false},
+		{"spam_complaint", false, false, false},
+		{"hard-bounce", false, false, false},
+	}, func(s string) lifecycle { return EventType(s) })
+}
+
+func TestIPStatus(t *testing.T) {
+	checkEnum(t, "IPStatus", []enumCase{
+		{"active", true, true, false},
+		{"inactive", true, true, false},
+		{"warming_up", true, false, false},
+		{"suspended", true, true, true},
+		{"blocked", false, false, false},
+		{"", false, false, false},
+	}, func(s string) lifecycle { return IPStatus(s) })
+}
+
+// TestEnumDecoding checks that decoded values keep their case, unknown values
+// included, while the helper methods ignore it.
+func TestEnumDecoding(t *testing.T) {
+	var schedule Schedule
+	if err := json.Unmarshal([]byte(`{"jobStatus":"FAILED","jobType":"Marketing"}`), &schedule); err != nil {
+		t.Fatal(err)
+	}
+	if schedule.JobStatus != "FAILED" || !schedule.JobStatus.IsFailure() || schedule.JobType != "Marketing" || !schedule.JobType.IsKnown() {
+		t.Errorf("got job status %q and type %q", schedule.JobStatus, schedule.JobType)
+	}
+
+	var info GetScheduleInfoResponse
+	if err := json.Unmarshal([]byte(`{"state":"Queued"}`), &info); err != nil {
+		t.Fatal(err)
+	}
+	if info.State != "Queued" || info.State.IsKnown() || info.State.String() != "Queued" {
+		t.Errorf("got state %q, want the unknown value unchanged", info.State)
+	}
+
+	var event EmailTransactionEvent
+	if err := json.Unmarshal([]byte(`{"eventType":"Hard_Bounce"}`), &event); err != nil {
+		t.Fatal(err)
+	}
+	if !event.EventType.IsTerminal() || !event.EventType.IsFailure() {
+		t.Errorf("got event type %q, want a terminal failure", event.EventType)
+	}
+
+	var warmup StartWarmUpResponse
+	if err := json.Unmarshal([]byte(`{"status":"WARMING_UP"}`), &warmup); err != nil {
+		t.Fatal(err)
+	}
+	if !warmup.Status.IsKnown() || warmup.Status.IsTerminal() {
+		t.Errorf("got IP status %q, want a known, unsettled status", warmup.Status)
+	}
+	// Values are encoded as they were received.
+	encoded, err := json.Marshal(map[string]interface{}{"state": info.State, "status": warmup.Status})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(encoded) != `{"state":"Queued","status":"WARMING_UP"}` {
+		t.Errorf("got %s after re-encoding", encoded)
+	}
+}
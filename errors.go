@@ -0,0 +1,49 @@
+package mepost
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// APIError is returned when the Mepost API responds with a non-2xx status.
+type APIError struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+	// Errors holds the errors reported by the API, if the body could be decoded.
+	Errors []ErrorResponse
+	// Header holds the response headers.
+	Header http.Header
+	// Body is the raw response body.
+	Body []byte
+}
+
+func (e *APIError) Error() string {
+	status := strings.TrimSpace(fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode)))
+	if len(e.Errors) == 0 {
+		body := strings.TrimSpace(string(e.Body))
+		if body == "" {
+			return fmt.Sprintf("mepost: %s", status)
+		}
+		if len(body) > 200 {
+			body = body[:200] + "..."
+		}
+		return fmt.Sprintf("mepost: %s: %s", status, body)
+	}
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Message
+	}
+	return fmt.Sprintf("mepost: %s: %s", status, strings.Join(messages, "; "))
+}
+
+// newAPIError builds an APIError from a non-2xx response body.
+func newAPIError(codec Codec, statusCode int, header http.Header, body []byte) *APIError {
+	apiErr := &APIError{StatusCode: statusCode, Header: header, Body: body}
+	var envelope ApiResponse[interface{}]
+	if err := codec.Unmarshal(body, &envelope); err == nil {
+		collectExtra(codec, body, &envelope)
+		apiErr.Errors = envelope.Errors
+	}
+	return apiErr
+}
@@ -0,0 +1,85 @@
+package mepost
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestNewAPIError(t *testing.T) {
+	long := strings.Repeat("x", 250)
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		message string
+		codes   string
+	}{
+		{"API errors", 400, `{"success":false,"errors":[{"code":1001,"message":"name is required","type":"validation"},{"code":1002,"message":"to is empty"}]}`,
+			"mepost: 400 Bad Request: name is required; to is empty", "[1001 1002]"},
+		{"envelope without errors", 404, `{"success":false}`, `mepost: 404 Not Found: {"success":false}`, "[]"},
+		{"plain text", 502, "  Bad gateway\n", "mepost: 502 Bad Gateway: Bad gateway", "[]"},
+		{"HTML", 503, "<html><body>Service Unavailable</body></html>", "mepost: 503 Service Unavailable: <html><body>Service Unavailable</body></html>", "[]"},
+		{"empty", 401, "", "mepost: 401 Unauthorized", "[]"},
+		{"long body", 500, long, "mepost: 500 Internal Server Error: " + long[:200] + "...", "[]"},
+		{"unknown status", 599, "", "mepost: 599", "[]"},
+	}
+	for _, tt := range tests {
+		header := http.Header{"X-Request-Id": {"req-1"}}
+		err := newAPIError(JSONCodec{}, tt.status, header, []byte(tt.body))
+		if got := err.Error(); got != tt.message {
+			t.Errorf("%s: got message %q, want %q", tt.name, got, tt.message)
+		}
+		codes := make([]int, len(err.Errors))
+		for i, e := range err.Errors {
+			codes[i] = e.Code
+		}
+		if got := fmt.Sprint(codes); got != tt.codes {
+			t.Errorf("%s: got error codes %s, want %s", tt.name, got, tt.codes)
+		}
+		if err.StatusCode != tt.status || string(err.Body) != tt.body || err.Header.Get("X-Request-Id") != "req-1" {
+			t.Errorf("%s: got %+v", tt.name, err)
+		}
+	}
+}
+
+// TestAPIErrorClassification checks how the retry policy, the circuit breaker
+// and ErrorKind treat API errors by status.
+func TestAPIErrorClassification(t *testing.T) {
+	tests := []struct {
+		status int
+		// retryGet and retryPost report whether GET and POST requests are retried.
+		retryGet, retryPost bool
+		breakerFailure      bool
+	}{
+		{http.StatusBadRequest, false, false, false},
+		{http.StatusUnauthorized, false, false, false},
+		{http.StatusNotFound, false, false, false},
+		{http.StatusConflict, false, false, false},
+		{http.StatusTooManyRequests, true, true, false},
+		{http.StatusInternalServerError, true, false, true},
+		{http.StatusNotImplemented, false, false, true},
+		{http.StatusBadGateway, true, false, true},
+		{http.StatusServiceUnavailable, true, false, true},
+		{http.StatusGatewayTimeout, true, false, true},
+	}
+	for _, tt := range tests {
+		var err error = newAPIError(JSONCodec{}, tt.status, http.Header{}, nil)
+		// Classification sees through wrapping.
+		err = fmt.Errorf("listing groups: %w", err)
+		if got := retryable(http.MethodGet, err); got != tt.retryGet {
+			t.Errorf("%d: GET retryable is %v, want %v", tt.status, got, tt.retryGet)
+		}
+		if got := retryable(http.MethodPost, err); got != tt.retryPost {
+			t.Errorf("%d: POST retryable is %v, want %v", tt.status, got, tt.retryPost)
+		}
+		if failed, counted := breakerOutcome(context.Background(), err); failed != tt.breakerFailure || !counted {
+			t.Errorf("%d: breaker outcome is failed=%v counted=%v, want %v true", tt.status, failed, counted, tt.breakerFailure)
+		}
+		if kind := ErrorKind(err); kind != "api" {
+			t.Errorf("%d: got error kind %q, want api", tt.status, kind)
+		}
+	}
+}
@@ -0,0 +1,356 @@
+package mepost
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Extension holds the parts of a response that the SDK does not model.
+// It is embedded in every response type.
+//
+// Unknown fields are only collected when Client.KeepRawResponse or
+// Client.StrictDecoding is set, as finding them means decoding the response's
+// objects a second time, into maps of raw values with the client's Codec.
+type Extension struct {
+	// Extra contains the fields of the JSON object that have no matching struct field,
+	// keyed by their JSON name. It is nil when every field was recognized, and
+	// when unknown fields are not collected.
+	Extra map[string]json.RawMessage `json:"-"`
+	// Raw is the undecoded response body. It is only set on the top-level response
+	// value, and only when Client.KeepRawResponse is enabled.
+	Raw json.RawMessage `json:"-"`
+}
+
+func (e *Extension) extension() *Extension {
+	return e
+}
+
+// extensible is implemented by every type that embeds Extension.
+type extensible interface {
+	extension() *Extension
+}
+
+// UnknownFieldsError is returned in strict decoding mode when a response
+// contains fields the SDK does not model.
+type UnknownFieldsError struct {
+	// Fields lists the unknown fields as JSON paths, e.g. "data[0].newField".
+	Fields []string
+}
+
+func (e *UnknownFieldsError) Error() string {
+	return fmt.Sprintf("mepost: response contains unknown fields: %s", strings.Join(e.Fields, ", "))
+}
+
+// collectExtra stores the fields of the JSON document data that have no matching
+// struct field in the Extra field of the Extension embedded in each value of v,
+// which must be the pointer data was decoded into. The objects of data are
+// decoded into maps of raw values with codec, and their keys compared with the
+// JSON names of the struct fields. Only values whose type embeds Extension
+// somewhere are descended into.
+func collectExtra(codec Codec, data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if !collectsExtra(v) || rv.Kind() != reflect.Ptr || rv.IsNil() || len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+	return extraValue(codec, data, rv)
+}
+
+// collectsExtra reports whether values decoded into v have unknown fields to collect.
+func collectsExtra(v interface{}) bool {
+	return v != nil && hasExtension(reflect.TypeOf(v))
+}
+
+var (
+	extensionType   = reflect.TypeOf(Extension{})
+	unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textType        = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// structInfo describes how the JSON fields of a struct type map to its fields.
+type structInfo struct {
+	// fields maps JSON names, and their lower-cased form since encoding/json
+	// matches keys case-insensitively, to fields.
+	fields map[string]fieldInfo
+	// extension is the index of the embedded Extension, or nil.
+	extension []int
+}
+
+type fieldInfo struct {
+	index []int
+	// descend is set if the field's value can contain an Extension.
+	descend bool
+}
+
+var (
+	structInfoCache   sync.Map
+	hasExtensionCache sync.Map
+)
+
+func structInfoOf(t reflect.Type) *structInfo {
+	if cached, ok := structInfoCache.Load(t); ok {
+		return cached.(*structInfo)
+	}
+	info := &structInfo{fields: make(map[string]fieldInfo)}
+	collectStructInfo(t, nil, info)
+	for name, field := range info.fields {
+		field.descend = hasExtension(t.FieldByIndex(field.index).Type)
+		info.fields[name] = field
+	}
+	structInfoCache.Store(t, info)
+	return info
+}
+
+func collectStructInfo(t reflect.Type, index []int, info *structInfo) {
+	var embedded []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		field.Index = append(append([]int(nil), index...), i)
+		if field.Type == extensionType {
+			if info.extension == nil {
+				info.extension = field.Index
+			}
+			continue
+		}
+		name, ok := jsonName(field)
+		if !ok {
+			continue
+		}
+		if name == "" {
+			embedded = append(embedded, field)
+			continue
+		}
+		// Fields of the outer struct take precedence over promoted ones.
+		if _, ok := info.fields[name]; !ok {
+			info.fields[name] = fieldInfo{index: field.Index}
+		}
+		if lower := strings.ToLower(name); lower != name {
+			if _, ok := info.fields[lower]; !ok {
+				info.fields[lower] = fieldInfo{index: field.Index}
+			}
+		}
+	}
+	for _, field := range embedded {
+		t := field.Type
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		collectStructInfo(t, field.Index, info)
+	}
+}
+
+// opaque reports whether values of type t decode themselves, so that their
+// JSON is not matched against their fields.
+func opaque(t reflect.Type) bool {
+	if t.Kind() != reflect.Ptr {
+		t = reflect.PointerTo(t)
+	}
+	return t.Implements(unmarshalerType) || t.Implements(textType)
+}
+
+// hasExtension reports whether values of type t can contain an Extension.
+func hasExtension(t reflect.Type) bool {
+	if cached, ok := hasExtensionCache.Load(t); ok {
+		return cached.(bool)
+	}
+	found := searchExtension(t, make(map[reflect.Type]bool))
+	hasExtensionCache.Store(t, found)
+	return found
+}
+
+// searchExtension implements hasExtension. visiting holds the types being
+// searched, which recursive types would otherwise search forever.
+func searchExtension(t reflect.Type, visiting map[reflect.Type]bool) bool {
+	if visiting[t] {
+		return false
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return searchExtension(t.Elem(), visiting)
+	case reflect.Struct:
+		if opaque(t) {
+			return false
+		}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.Type == extensionType || searchExtension(field.Type, visiting) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// extraValue collects the unknown fields of the JSON value data, which was
+// decoded into v.
+func extraValue(codec Codec, data json.RawMessage, v reflect.Value) error {
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if !v.IsValid() || !hasExtension(v.Type()) || string(data) == "null" {
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		return extraObject(codec, data, v)
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String || v.IsNil() {
+			return nil
+		}
+		var members map[string]json.RawMessage
+		if err := codec.Unmarshal(data, &members); err != nil {
+			return err
+		}
+		for key, member := range members {
+			k := reflect.ValueOf(key).Convert(v.Type().Key())
+			elem := v.MapIndex(k)
+			if !elem.IsValid() {
+				continue
+			}
+			// Map elements are not addressable, so a copy is updated and stored back.
+			copied := reflect.New(elem.Type()).Elem()
+			copied.Set(elem)
+			if err := extraValue(codec, member, copied); err != nil {
+				return err
+			}
+			v.SetMapIndex(k, copied)
+		}
+	case reflect.Slice, reflect.Array:
+		var elems []json.RawMessage
+		if err := codec.Unmarshal(data, &elems); err != nil {
+			return err
+		}
+		for i := 0; i < len(elems) && i < v.Len(); i++ {
+			if err := extraValue(codec, elems[i], v.Index(i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func extraObject(codec Codec, data json.RawMessage, v reflect.Value) error {
+	var members map[string]json.RawMessage
+	if err := codec.Unmarshal(data, &members); err != nil {
+		return err
+	}
+	info := structInfoOf(v.Type())
+	var extra map[string]json.RawMessage
+	for key, member := range members {
+		field, ok := info.fields[key]
+		if !ok {
+			field, ok = info.fields[strings.ToLower(key)]
+		}
+		if !ok {
+			if extra == nil {
+				extra = make(map[string]json.RawMessage)
+			}
+			extra[key] = member
+			continue
+		}
+		if !field.descend {
+			continue
+		}
+		// Fields promoted through nil embedded pointers were not decoded.
+		if fv, err := v.FieldByIndexErr(field.index); err == nil {
+			if err := extraValue(codec, member, fv); err != nil {
+				return err
+			}
+		}
+	}
+	if info.extension != nil {
+		v.FieldByIndex(info.extension).Addr().Interface().(*Extension).Extra = extra
+	}
+	return nil
+}
+
+// jsonName returns the JSON name of a struct field, or an empty name for an
+// untagged embedded struct whose fields are promoted. ok is false for fields
+// that encoding/json ignores.
+func jsonName(field reflect.StructField) (name string, ok bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	name, _, _ = strings.Cut(tag, ",")
+	if name != "" {
+		return name, true
+	}
+	if field.Anonymous {
+		t := field.Type
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() == reflect.Struct {
+			return "", true
+		}
+	}
+	if !field.IsExported() {
+		return "", false
+	}
+	return field.Name, true
+}
+
+// checkUnknownFields walks a decoded response and returns an UnknownFieldsError
+// if any value in it recorded extra fields.
+func checkUnknownFields(v interface{}) error {
+	var fields []string
+	walkExtra(reflect.ValueOf(v), "", &fields)
+	if len(fields) == 0 {
+		return nil
+	}
+	sort.Strings(fields)
+	return &UnknownFieldsError{Fields: fields}
+}
+
+func walkExtra(v reflect.Value, path string, fields *[]string) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			walkExtra(v.Elem(), path, fields)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			walkExtra(v.Index(i), fmt.Sprintf("%s[%d]", path, i), fields)
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			walkExtra(iter.Value(), fmt.Sprintf("%s[%v]", path, iter.Key()), fields)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.Type == reflect.TypeOf(Extension{}) {
+				for name := range v.Field(i).Interface().(Extension).Extra {
+					*fields = append(*fields, joinPath(path, name))
+				}
+				continue
+			}
+			name, ok := jsonName(field)
+			if !ok {
+				continue
+			}
+			if name == "" {
+				walkExtra(v.Field(i), path, fields)
+				continue
+			}
+			walkExtra(v.Field(i), joinPath(path, name), fields)
+		}
+	}
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
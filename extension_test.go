@@ -0,0 +1,101 @@
+package mepost
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// countingCodec is JSONCodec counting the values it unmarshals.
+type countingCodec struct {
+	JSONCodec
+	unmarshals int
+}
+
+func (c *countingCodec) Unmarshal(data []byte, v interface{}) error {
+	c.unmarshals++
+	return c.JSONCodec.Unmarshal(data, v)
+}
+
+type extraInner struct {
+	Extension
+	Name string `json:"name"`
+}
+
+// ExtraEmbedded is exported because encoding/json cannot allocate embedded
+// pointers to unexported types.
+type ExtraEmbedded struct {
+	Promoted string `json:"promoted"`
+}
+
+type extraOuter struct {
+	Extension
+	*ExtraEmbedded
+	ID       int                   `json:"id"`
+	Items    []extraInner          `json:"items"`
+	ByName   map[string]extraInner `json:"byName"`
+	Pointer  *extraInner           `json:"pointer"`
+	Plain    map[string]int        `json:"plain"`
+	Untagged string
+	Ignored  string `json:"-"`
+}
+
+func TestCollectExtra(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		// unknown lists the paths of the unknown fields.
+		unknown string
+	}{
+		{"known fields", `{"id":1,"ID":2,"untagged":"u","promoted":"p","plain":{"a":1},"items":[{"name":"a"}]}`, ""},
+		{"top level", `{"id":1,"Ignored":"x","new":{"a":[1,2]},"café":true}`, "Ignored,café,new"},
+		{"slice", `{"items":[{"name":"a"},{"name":"b","extra":1}]}`, "items[1].extra"},
+		{"map", `{"byName":{"k":{"name":"n","other":"v"}}}`, "byName[k].other"},
+		{"pointer", `{"pointer":{"p":null}}`, "pointer.p"},
+		{"null", `null`, ""},
+		{"empty", ``, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v extraOuter
+			if tt.data != "" {
+				if err := json.Unmarshal([]byte(tt.data), &v); err != nil {
+					t.Fatal(err)
+				}
+			}
+			codec := &countingCodec{}
+			if err := collectExtra(codec, []byte(tt.data), &v); err != nil {
+				t.Fatal(err)
+			}
+			if strings.HasPrefix(tt.data, "{") && codec.unmarshals == 0 {
+				t.Error("unknown fields were not decoded with the codec")
+			}
+			var got string
+			var unknown *UnknownFieldsError
+			if err := checkUnknownFields(&v); errors.As(err, &unknown) {
+				got = strings.Join(unknown.Fields, ",")
+			}
+			if got != tt.unknown {
+				t.Errorf("got unknown fields %q, want %q", got, tt.unknown)
+			}
+		})
+	}
+}
+
+func TestCollectExtraValues(t *testing.T) {
+	data := `{"new":{"a":[1, 2]},"items":[{"extra":"x"}]}`
+	var v extraOuter
+	if err := json.Unmarshal([]byte(data), &v); err != nil {
+		t.Fatal(err)
+	}
+	if err := collectExtra(JSONCodec{}, []byte(data), &v); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(v.Extra["new"]); got != `{"a":[1, 2]}` {
+		t.Errorf("got top-level extra %s", got)
+	}
+	if got := string(v.Items[0].Extra["extra"]); got != `"x"` {
+		t.Errorf("got nested extra %s", got)
+	}
+}
@@ -0,0 +1,294 @@
+package mepost
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The helpers below map Go structs to and from subscriber custom fields and
+// recipient customization values. Fields are mapped through `mepost` struct tags:
+//
+//	type Profile struct {
+//		Plan      string     `mepost:"plan"`
+//		Seats     int        `mepost:"seats,omitempty"`
+//		Trial     bool       `mepost:"trial"`
+//		RenewsAt  *time.Time `mepost:"renews_at"`
+//		Internal  string     `mepost:"-"`
+//	}
+//
+// Untagged fields are ignored, except that the fields of untagged embedded
+// structs are included as if they belonged to the outer struct. Strings,
+// booleans, integers, floats, time.Time (RFC 3339), types implementing
+// encoding.TextMarshaler/TextUnmarshaler and pointers to any of these are
+// supported. Nil pointers and, with omitempty,
+// zero values are left out when encoding. When decoding, fields that are not
+// present leave the struct field untouched, and empty values set it to its zero value.
+
+var errUnsupportedType = errors.New("unsupported type")
+
+// FieldError is returned when a struct field cannot be converted to or from a custom field value.
+type FieldError struct {
+	// Field is the custom field name from the struct tag.
+	Field string
+	// Value is the value being decoded; it is empty when encoding.
+	Value string
+	// Type is the type of the struct field.
+	Type reflect.Type
+	Err  error
+}
+
+func (e *FieldError) Error() string {
+	if e.Value != "" {
+		return fmt.Sprintf("mepost: custom field %q: cannot convert %q to %s: %v", e.Field, e.Value, e.Type, e.Err)
+	}
+	return fmt.Sprintf("mepost: custom field %q: cannot convert %s: %v", e.Field, e.Type, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// EncodeCustomFields converts the tagged fields of the struct v into subscriber custom fields.
+func EncodeCustomFields(v interface{}) ([]CustomField, error) {
+	var fields []CustomField
+	err := encodeTagged(v, func(name, value string) {
+		fields = append(fields, CustomField{Name: name, Value: value})
+	})
+	return fields, err
+}
+
+// DecodeCustomFields sets the tagged fields of the struct pointed to by v from subscriber custom fields.
+func DecodeCustomFields(fields []CustomField, v interface{}) error {
+	values := make(map[string]string, len(fields))
+	for _, field := range fields {
+		values[field.Name] = field.Value
+	}
+	return decodeTagged(values, v)
+}
+
+// EncodeCustomization converts the tagged fields of the struct v into a customization map.
+func EncodeCustomization(v interface{}) (map[string]string, error) {
+	values := make(map[string]string)
+	err := encodeTagged(v, func(name, value string) {
+		values[name] = value
+	})
+	return values, err
+}
+
+// DecodeCustomization sets the tagged fields of the struct pointed to by v from a customization map.
+func DecodeCustomization(values map[string]string, v interface{}) error {
+	return decodeTagged(values, v)
+}
+
+// taggedField is a struct field with a `mepost` tag.
+type taggedField struct {
+	name      string
+	omitEmpty bool
+	value     reflect.Value
+	// depth is the number of embedded structs the field is promoted through.
+	depth int
+}
+
+// taggedFields returns the tagged fields of the struct v, including those of
+// embedded structs. As with encoding/json, a field hides fields of the same
+// name in more deeply embedded structs. Two fields of the same name at the
+// same depth are an error.
+func taggedFields(v reflect.Value) ([]taggedField, error) {
+	all := collectTagged(v, 0)
+	shallowest := make(map[string]int, len(all))
+	for _, field := range all {
+		if depth, ok := shallowest[field.name]; !ok || field.depth < depth {
+			shallowest[field.name] = field.depth
+		}
+	}
+	fields := all[:0]
+	seen := make(map[string]bool, len(all))
+	for _, field := range all {
+		if field.depth != shallowest[field.name] {
+			continue
+		}
+		if seen[field.name] {
+			return nil, fmt.Errorf("mepost: custom field %q is defined more than once in %s", field.name, v.Type())
+		}
+		seen[field.name] = true
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+func collectTagged(v reflect.Value, depth int) []taggedField {
+	var fields []taggedField
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, ok := field.Tag.Lookup("mepost")
+		if !ok {
+			if field.Anonymous && field.Type.Kind() == reflect.Struct {
+				fields = append(fields, collectTagged(v.Field(i), depth+1)...)
+			}
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, taggedField{
+			name:      name,
+			omitEmpty: opts == "omitempty",
+			value:     v.Field(i),
+			depth:     depth,
+		})
+	}
+	return fields
+}
+
+func encodeTagged(v interface{}, emit func(name, value string)) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return errors.New("mepost: cannot encode custom fields from a nil pointer")
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("mepost: cannot encode custom fields from %T, expected a struct", v)
+	}
+	fields, err := taggedFields(rv)
+	if err != nil {
+		return err
+	}
+	for _, field := range fields {
+		value := field.value
+		if value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				continue
+			}
+			value = value.Elem()
+		}
+		if field.omitEmpty && value.IsZero() {
+			continue
+		}
+		str, err := formatValue(value)
+		if err != nil {
+			return &FieldError{Field: field.name, Type: field.value.Type(), Err: err}
+		}
+		emit(field.name, str)
+	}
+	return nil
+}
+
+func decodeTagged(values map[string]string, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("mepost: cannot decode custom fields into %T, expected a pointer to a struct", v)
+	}
+	fields, err := taggedFields(rv.Elem())
+	if err != nil {
+		return err
+	}
+	for _, field := range fields {
+		str, ok := values[field.name]
+		if !ok {
+			continue
+		}
+		target := field.value
+		if target.Kind() == reflect.Ptr {
+			if str == "" {
+				target.Set(reflect.Zero(target.Type()))
+				continue
+			}
+			if target.IsNil() {
+				target.Set(reflect.New(target.Type().Elem()))
+			}
+			target = target.Elem()
+		}
+		if err := parseValue(str, target); err != nil {
+			return &FieldError{Field: field.name, Value: str, Type: field.value.Type(), Err: err}
+		}
+	}
+	return nil
+}
+
+func formatValue(v reflect.Value) (string, error) {
+	if t, ok := v.Interface().(time.Time); ok {
+		return t.Format(time.RFC3339Nano), nil
+	}
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		text, err := m.MarshalText()
+		return string(text), err
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()), nil
+	}
+	return "", errUnsupportedType
+}
+
+func parseValue(str string, v reflect.Value) error {
+	if v.Type() == reflect.TypeOf(time.Time{}) {
+		if str == "" {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		t, err := parseTimeString(str)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(str))
+	}
+	if str == "" {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(str)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(str)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(str), 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(strings.TrimSpace(str), 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(str), v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return errUnsupportedType
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package mepost
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type billing struct {
+	Plan    string `mepost:"plan"`
+	Seats   int    `mepost:"seats,omitempty"`
+	Company string `mepost:"company"`
+}
+
+type profile struct {
+	billing
+	Name     string     `mepost:"name"`
+	Trial    bool       `mepost:"trial"`
+	Score    float64    `mepost:"score,omitempty"`
+	Credits  uint16     `mepost:"credits"`
+	RenewsAt *time.Time `mepost:"renews_at"`
+	Signup   time.Time  `mepost:"signup"`
+	IP       netip.Addr `mepost:"ip,omitempty"`
+	Referrer *string    `mepost:"referrer"`
+	Company  string     `mepost:"company"`
+	Default  string     `mepost:""`
+	Internal string     `mepost:"-"`
+	Untagged string
+	hidden   string `mepost:"hidden"`
+}
+
+func customFields(fields []CustomField) string {
+	var s string
+	for _, field := range fields {
+		s += fmt.Sprintf("%s=%s;", field.Name, field.Value)
+	}
+	return s
+}
+
+func TestCustomFieldsRoundTrip(t *testing.T) {
+	renews := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	in := profile{
+		billing:  billing{Plan: "pro", Company: "hidden by profile.Company"},
+		Name:     "Alice",
+		Trial:    true,
+		Credits:  500,
+		RenewsAt: &renews,
+		IP:       netip.MustParseAddr("192.0.2.1"),
+		Company:  "Acme",
+		Default:  "x",
+		Internal: "secret",
+		Untagged: "y",
+		hidden:   "z",
+	}
+	fields, err := EncodeCustomFields(&in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Zero Seats and Score are omitted, the nil Referrer is left out, and the
+	// zero Signup is sent because it has no omitempty.
+	want := "plan=pro;name=Alice;trial=true;credits=500;renews_at=2024-05-01T12:30:00Z;signup=0001-01-01T00:00:00Z;ip=192.0.2.1;company=Acme;Default=x;"
+	if got := customFields(fields); got != want {
+		t.Errorf("got custom fields\n%s\nwant\n%s", got, want)
+	}
+
+	var out profile
+	if err := DecodeCustomFields(fields, &out); err != nil {
+		t.Fatal(err)
+	}
+	in.Internal, in.Untagged, in.hidden = "", "", ""
+	// The embedded Company is hidden by the outer one.
+	in.billing.Company = ""
+	if !reflect.DeepEqual(out, in) {
+		t.Errorf("got %+v after a round trip, want %+v", out, in)
+	}
+
+	values, err := EncodeCustomization(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != len(fields) || values["plan"] != "pro" || values["company"] != "Acme" {
+		t.Errorf("got customization %v", values)
+	}
+	out = profile{}
+	if err := DecodeCustomization(values, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(out, in) {
+		t.Errorf("got %+v after a customization round trip, want %+v", out, in)
+	}
+}
+
+func TestDecodeCustomization(t *testing.T) {
+	referrer := "newsletter"
+	out := profile{
+		billing:  billing{Plan: "pro", Seats: 3},
+		Name:     "Alice",
+		Score:    0.5,
+		Referrer: &referrer,
+	}
+	// Missing values leave fields untouched and empty values reset them.
+	err := DecodeCustomization(map[string]string{"seats": " 10 ", "name": "", "referrer": "", "score": "1e3", "unknown": "x"}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := profile{billing: billing{Plan: "pro", Seats: 10}, Score: 1000}
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("got %+v, want %+v", out, want)
+	}
+
+	err = DecodeCustomization(map[string]string{"referrer": "ads"}, &out)
+	if err != nil || out.Referrer == nil || *out.Referrer != "ads" {
+		t.Errorf("got referrer %v and error %v, want a pointer to ads", out.Referrer, err)
+	}
+}
+
+func TestCustomFieldErrors(t *testing.T) {
+	type unsupported struct {
+		Tags []string `mepost:"tags"`
+	}
+	type nested struct {
+		Billing billing `mepost:"billing"`
+	}
+	type collision struct {
+		First  string `mepost:"plan"`
+		Second string `mepost:"plan"`
+	}
+	type embeddedCollision struct {
+		billing
+		collision
+	}
+	type hidden struct {
+		billing
+		collision
+		Plan string `mepost:"plan"`
+	}
+
+	tests := []struct {
+		name   string
+		encode interface{}
+		decode interface{}
+		values map[string]string
+		// encodeErr and decodeErr are the expected error prefixes, or empty
+		// if EncodeCustomFields is not called.
+		encodeErr, decodeErr string
+		// field is the field reported by a FieldError, if one is expected.
+		field string
+	}{
+		{"slice", unsupported{}, &unsupported{}, map[string]string{"tags": "a,b"},
+			`mepost: custom field "tags": cannot convert []string: unsupported type`,
+			`mepost: custom field "tags": cannot convert "a,b" to []string: unsupported type`, "tags"},
+		{"tagged struct", nested{}, &nested{}, map[string]string{"billing": "pro"},
+			`mepost: custom field "billing": cannot convert mepost.billing: unsupported type`,
+			`mepost: custom field "billing": cannot convert "pro" to mepost.billing: unsupported type`, "billing"},
+		{"invalid int", nil, &profile{}, map[string]string{"seats": "many"}, "",
+			`mepost: custom field "seats": cannot convert "many" to int: strconv.ParseInt: parsing "many": invalid syntax`, "seats"},
+		{"out of range", nil, &profile{}, map[string]string{"credits": "70000"}, "",
+			`mepost: custom field "credits": cannot convert "70000" to uint16: strconv.ParseUint: parsing "70000": value out of range`, "credits"},
+		{"invalid time", nil, &profile{}, map[string]string{"renews_at": "soon"}, "",
+			`mepost: custom field "renews_at": cannot convert "soon" to *time.Time: `, "renews_at"},
+		{"same name", collision{}, &collision{}, nil,
+			`mepost: custom field "plan" is defined more than once in mepost.collision`,
+			`mepost: custom field "plan" is defined more than once in mepost.collision`, ""},
+		{"same depth", embeddedCollision{}, &embeddedCollision{}, nil,
+			`mepost: custom field "plan" is defined more than once in mepost.embeddedCollision`,
+			`mepost: custom field "plan" is defined more than once in mepost.embeddedCollision`, ""},
+		{"not a struct", "plan", "plan", nil,
+			"mepost: cannot encode custom fields from string, expected a struct",
+			"mepost: cannot decode custom fields into string, expected a pointer to a struct", ""},
+		{"nil pointer", (*profile)(nil), (*profile)(nil), nil,
+			"mepost: cannot encode custom fields from a nil pointer",
+			"mepost: cannot decode custom fields into *mepost.profile, expected a pointer to a struct", ""},
+		{"struct value", nil, profile{}, nil, "",
+			"mepost: cannot decode custom fields into mepost.profile, expected a pointer to a struct", ""},
+	}
+	for _, tt := range tests {
+		check := func(op string, err error, want string) {
+			if err == nil || !strings.HasPrefix(err.Error(), want) {
+				t.Errorf("%s: %s returned %v, want %q", tt.name, op, err, want)
+			}
+			var fieldErr *FieldError
+			if errors.As(err, &fieldErr) != (tt.field != "") || (fieldErr != nil && fieldErr.Field != tt.field) {
+				t.Errorf("%s: %s returned %#v, want a FieldError for %q", tt.name, op, err, tt.field)
+			}
+		}
+		if tt.encodeErr != "" {
+			_, err := EncodeCustomFields(tt.encode)
+			check("encode", err, tt.encodeErr)
+		}
+		check("decode", DecodeCustomization(tt.values, tt.decode), tt.decodeErr)
+	}
+
+	// A field hides fields of the same name in embedded structs, even if they collide.
+	fields, err := EncodeCustomFields(hidden{billing: billing{Plan: "basic"}, Plan: "pro"})
+	if err != nil || customFields(fields) != "company=;plan=pro;" {
+		t.Errorf("got custom fields %s and error %v, want the outer plan", customFields(fields), err)
+	}
+}
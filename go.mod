@@ -1,3 +1,3 @@
 module github.com/mepost-io/golang-sdk
 
-go 1.19
+go 1.21
@@ -0,0 +1,191 @@
+package mepost
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// GroupsService manages email groups.
+type GroupsService struct {
+	client *Client
+}
+
+// GroupSortField is a field email groups can be sorted by.
+type GroupSortField string
+
+// Group sort fields.
+const (
+	GroupSortByName            GroupSortField = "name"
+	GroupSortByCreatedAt       GroupSortField = "createdAt"
+	GroupSortByUpdatedAt       GroupSortField = "updatedAt"
+	GroupSortByTotalSubscriber GroupSortField = "totalSubscriber"
+)
+
+// ListGroupsOptions holds the options for listing email groups.
+//
+// Filters and sorting are sent to the API and also applied to the returned page,
+// so results are correct even where the API does not support them. In that case
+// a page may hold fewer than Limit groups, Total still counts all groups, and
+// sorting only orders the groups within the page; use GroupsService.ListAll to
+// sort across pages.
+type ListGroupsOptions struct {
+	// Limit is the maximum number of groups to return.
+	Limit int
+	// Page is the page number, starting at 1.
+	Page int
+	// Search matches groups whose name contains the given text, ignoring case.
+	Search string
+	// Created and Updated restrict the creation and last update times.
+	Created TimeRange
+	Updated TimeRange
+	// SortBy and Order sort the results.
+	SortBy GroupSortField
+	Order  SortOrder
+}
+
+func (o *ListGroupsOptions) query() url.Values {
+	query := pageQuery(o.Limit, o.Page)
+	if o.Search != "" {
+		query.Set("search", o.Search)
+	}
+	o.Created.setQuery(query, "created")
+	o.Updated.setQuery(query, "updated")
+	if o.SortBy != "" {
+		query.Set("sort", string(o.SortBy))
+	}
+	if o.Order != "" {
+		query.Set("order", string(o.Order))
+	}
+	return query
+}
+
+func (o *ListGroupsOptions) match(g *EmailGroup) bool {
+	if o.Search != "" && !strings.Contains(strings.ToLower(g.Name), strings.ToLower(o.Search)) {
+		return false
+	}
+	return o.Created.contains(g.CreatedAt.Time) && o.Updated.contains(g.UpdatedAt.Time)
+}
+
+// apply filters and sorts groups in place and returns the filtered slice.
+func (o *ListGroupsOptions) apply(groups []EmailGroup) []EmailGroup {
+	filtered := groups[:0]
+	for i := range groups {
+		if o.match(&groups[i]) {
+			filtered = append(filtered, groups[i])
+		}
+	}
+	if o.SortBy == "" {
+		return filtered
+	}
+	sort.SliceStable(filtered, func(i, j int) bool {
+		a, b := &filtered[i], &filtered[j]
+		switch o.SortBy {
+		case GroupSortByName:
+			return less(strings.ToLower(a.Name), strings.ToLower(b.Name), o.Order)
+		case GroupSortByCreatedAt:
+			return less(a.CreatedAt.UnixNano(), b.CreatedAt.UnixNano(), o.Order)
+		case GroupSortByUpdatedAt:
+			return less(a.UpdatedAt.UnixNano(), b.UpdatedAt.UnixNano(), o.Order)
+		case GroupSortByTotalSubscriber:
+			return less(a.TotalSubscriber, b.TotalSubscriber, o.Order)
+		}
+		return false
+	})
+	return filtered
+}
+
+// List retrieves a page of email groups. opts may be nil.
+func (s *GroupsService) List(ctx context.Context, opts *ListGroupsOptions) (*BaseResult[EmailGroup], error) {
+	if opts == nil {
+		opts = &ListGroupsOptions{}
+	}
+	response, err := s.list(ctx, opts)
+	if err != nil {
+		return response, err
+	}
+	response.Data = opts.apply(response.Data)
+	return response, nil
+}
+
+// ListAll retrieves every email group that matches opts, following pagination,
+// and sorts them all. opts.Limit sets the page size and defaults to 100;
+// opts.Page is ignored.
+func (s *GroupsService) ListAll(ctx context.Context, opts *ListGroupsOptions) ([]EmailGroup, error) {
+	pageOpts := ListGroupsOptions{}
+	if opts != nil {
+		pageOpts = *opts
+	}
+	if pageOpts.Limit <= 0 {
+		pageOpts.Limit = 100
+	}
+	var groups []EmailGroup
+	for page := 1; ; page++ {
+		pageOpts.Page = page
+		response, err := s.list(ctx, &pageOpts)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, response.Data...)
+		if lastPage(len(response.Data), pageOpts.Limit, len(groups), response.Total) {
+			break
+		}
+	}
+	return pageOpts.apply(groups), nil
+}
+
+// list retrieves a page of groups without applying the filters locally.
+func (s *GroupsService) list(ctx context.Context, opts *ListGroupsOptions) (*BaseResult[EmailGroup], error) {
+	url, err := s.client.buildURL(opts.query(), "groups")
+	if err != nil {
+		return nil, err
+	}
+	response := &BaseResult[EmailGroup]{}
+	err = s.client.makeRequest(ctx, OpListGroups, "GET", url, nil, response)
+	return response, err
+}
+
+// Get retrieves an email group and its subscriber counts.
+func (s *GroupsService) Get(ctx context.Context, groupID string) (*EmailGroupWithCounts, error) {
+	url, err := s.client.buildURL(nil, "groups", groupID)
+	if err != nil {
+		return nil, err
+	}
+	response := &EmailGroupWithCounts{}
+	err = s.client.makeRequest(ctx, OpGetGroup, "GET", url, nil, response)
+	return response, err
+}
+
+// Create creates a new email group.
+func (s *GroupsService) Create(ctx context.Context, request CreateNewGroupRequest) (*EmailGroup, error) {
+	url, err := s.client.buildURL(nil, "groups")
+	if err != nil {
+		return nil, err
+	}
+	response := &EmailGroup{}
+	err = s.client.makeRequest(ctx, OpCreateGroup, "POST", url, request, response)
+	return response, err
+}
+
+// Update renames an email group.
+func (s *GroupsService) Update(ctx context.Context, groupID string, request RenameGroupRequest) (bool, error) {
+	url, err := s.client.buildURL(nil, "groups", groupID)
+	if err != nil {
+		return false, err
+	}
+	var response bool
+	err = s.client.makeRequest(ctx, OpUpdateGroup, "PUT", url, request, &response)
+	return response, err
+}
+
+// Delete deletes an email group.
+func (s *GroupsService) Delete(ctx context.Context, groupID string) (bool, error) {
+	url, err := s.client.buildURL(nil, "groups", groupID)
+	if err != nil {
+		return false, err
+	}
+	var response bool
+	err = s.client.makeRequest(ctx, OpDeleteGroup, "DELETE", url, nil, &response)
+	return response, err
+}
@@ -0,0 +1,162 @@
+package mepost
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"time"
+)
+
+// Tracer starts a span for every SDK operation. It can be backed by
+// OpenTelemetry or any other tracing library.
+type Tracer interface {
+	// Start starts a span named after the operation. The returned context is
+	// passed down the pipeline, so that spans of the HTTP transport nest in it.
+	Start(ctx context.Context, operation string) (context.Context, Span)
+}
+
+// Span is a single traced operation.
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	// RecordError marks the span as failed.
+	RecordError(err error)
+	End()
+}
+
+// Attribute is a key-value pair attached to a span. Value is a string, int or bool.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// Span attribute keys.
+const (
+	AttrOperation       = "mepost.operation"
+	AttrMethod          = "http.request.method"
+	AttrStatusCode      = "http.response.status_code"
+	AttrRetryCount      = "mepost.retry_count"
+	AttrRecipientCount  = "mepost.recipient_count"
+	AttrAttachmentBytes = "mepost.attachment_bytes"
+	AttrRequestID       = "mepost.request_id"
+	AttrCached          = "mepost.cached"
+)
+
+// Metrics receives a measurement of every SDK operation. An implementation
+// typically derives a latency histogram and counters of operations, errors and
+// retries from it; see the mepostprom package for a Prometheus adapter.
+type Metrics interface {
+	// RecordOperation is called when an operation completes. status is the HTTP
+	// status of the last response, or 0 if none was received, and err is nil on success.
+	RecordOperation(operation string, status int, err error, duration time.Duration, retries int)
+}
+
+// CacheMetrics is implemented by Metrics that count results served by
+// Client.Cache separately. For such calls RecordCacheHit is called instead of
+// RecordOperation, so that hits do not skew the latency of API requests. Other
+// Metrics see hits as operations with the status of the cached response.
+type CacheMetrics interface {
+	RecordCacheHit(operation string, duration time.Duration)
+}
+
+// ErrorKind classifies err for metric labels: "api" for errors returned by the
+// API, "canceled" for canceled or timed out contexts, "circuit_open" and
+// "rate_limited" for calls rejected by the client, and "transport" otherwise.
+// It returns an empty string for nil.
+func ErrorKind(err error) string {
+	var apiErr *APIError
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &apiErr):
+		return "api"
+	case errors.Is(err, ErrCircuitOpen):
+		return "circuit_open"
+	case errors.Is(err, ErrRateLimited):
+		return "rate_limited"
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return "canceled"
+	}
+	return "transport"
+}
+
+// instrument is the outermost middleware when a Tracer or Metrics is set.
+func (c *Client) instrument(next Handler) Handler {
+	return func(ctx context.Context, op *Operation, response interface{}) error {
+		var span Span
+		if c.Tracer != nil {
+			ctx, span = c.Tracer.Start(ctx, op.Name)
+			recipients, attachmentBytes := messageSize(op.Request)
+			span.SetAttributes(
+				Attribute{AttrOperation, op.Name},
+				Attribute{AttrMethod, op.Method},
+			)
+			if recipients > 0 {
+				span.SetAttributes(
+					Attribute{AttrRecipientCount, recipients},
+					Attribute{AttrAttachmentBytes, attachmentBytes},
+				)
+			}
+		}
+
+		start := time.Now()
+		err := next(ctx, op, response)
+		elapsed := time.Since(start)
+
+		retries := op.Attempts - 1
+		if retries < 0 {
+			retries = 0
+		}
+		if span != nil {
+			span.SetAttributes(Attribute{AttrRetryCount, retries})
+			if op.StatusCode != 0 {
+				span.SetAttributes(Attribute{AttrStatusCode, op.StatusCode})
+			}
+			if op.RequestID != "" {
+				span.SetAttributes(Attribute{AttrRequestID, op.RequestID})
+			}
+			if op.Cached {
+				span.SetAttributes(Attribute{AttrCached, true})
+			}
+			if err != nil {
+				span.RecordError(err)
+			}
+			span.End()
+		}
+		if cm, ok := c.Metrics.(CacheMetrics); ok && op.Cached {
+			cm.RecordCacheHit(op.Name, elapsed)
+		} else if c.Metrics != nil {
+			c.Metrics.RecordOperation(op.Name, op.StatusCode, err, elapsed, retries)
+		}
+		return err
+	}
+}
+
+// messageSize returns the number of recipients and the decoded size of the
+// attachments of a send request, or zeros for other requests.
+func messageSize(request interface{}) (recipients, attachmentBytes int) {
+	var attachments []AttachmentDto
+	switch r := request.(type) {
+	case *SendTransactionalRequest:
+		if r != nil {
+			return messageSize(*r)
+		}
+	case *SendMarketingRequest:
+		if r != nil {
+			return messageSize(*r)
+		}
+	case *SendMessageByTemplateRequest:
+		if r != nil {
+			return messageSize(*r)
+		}
+	case SendTransactionalRequest:
+		recipients, attachments = len(r.To), r.Attachments
+	case SendMarketingRequest:
+		recipients, attachments = len(r.To), r.Attachments
+	case SendMessageByTemplateRequest:
+		recipients, attachments = len(r.Message.To), r.Message.Attachments
+	}
+	for _, attachment := range attachments {
+		attachmentBytes += base64.StdEncoding.DecodedLen(len(attachment.Base64Content))
+	}
+	return recipients, attachmentBytes
+}
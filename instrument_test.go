@@ -0,0 +1,153 @@
+package mepost
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+type spanKey struct{}
+
+// testTracer records the spans it starts.
+type testTracer struct {
+	spans []*testSpan
+}
+
+type testSpan struct {
+	name  string
+	attrs []string
+	err   error
+	ended bool
+}
+
+func (t *testTracer) Start(ctx context.Context, operation string) (context.Context, Span) {
+	span := &testSpan{name: operation}
+	t.spans = append(t.spans, span)
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+func (s *testSpan) SetAttributes(attrs ...Attribute) {
+	for _, attr := range attrs {
+		s.attrs = append(s.attrs, fmt.Sprintf("%s=%v", attr.Key, attr.Value))
+	}
+}
+
+func (s *testSpan) RecordError(err error) { s.err = err }
+
+func (s *testSpan) End() { s.ended = true }
+
+func TestInstrumentSpans(t *testing.T) {
+	send := func(ctx context.Context, c *Client) error {
+		_, err := c.Messages.SendTransactional(ctx, SendTransactionalRequest{
+			FromEmail:   "noreply@example.com",
+			Subject:     "Receipt",
+			Text:        "Thanks",
+			To:          []To{{Email: "alice@example.com"}, {Email: "bob@example.com"}},
+			Attachments: []AttachmentDto{{FileName: "hello.txt", Base64Content: "aGVsbG8h"}},
+		})
+		return err
+	}
+	list := func(ctx context.Context, c *Client) error {
+		_, err := c.Outbound.IPs.List(ctx)
+		return err
+	}
+	tests := []struct {
+		name     string
+		failures int
+		setup    func(c *Client)
+		// calls is the number of times the operation is called; the last span is checked.
+		calls int
+		call  func(ctx context.Context, c *Client) error
+		span  string
+		err   string
+	}{
+		{"send", 0, nil, 1, send,
+			"SendTransactional: mepost.operation=SendTransactional, http.request.method=POST, mepost.recipient_count=2, mepost.attachment_bytes=6, " +
+				"mepost.retry_count=0, http.response.status_code=200, mepost.request_id=req-1", ""},
+		{"retried", 1, nil, 1, list,
+			"ListIPs: mepost.operation=ListIPs, http.request.method=GET, mepost.retry_count=1, http.response.status_code=200, mepost.request_id=req-2", ""},
+		{"failed", 2, nil, 1, list,
+			"ListIPs: mepost.operation=ListIPs, http.request.method=GET, mepost.retry_count=1, http.response.status_code=503, mepost.request_id=req-2",
+			"mepost: 503 Service Unavailable"},
+		{"cached", 0, func(c *Client) { c.Cache = NewCache(DefaultCacheTTL) }, 2, list,
+			"ListIPs: mepost.operation=ListIPs, http.request.method=GET, mepost.retry_count=0, http.response.status_code=200, mepost.request_id=req-1, mepost.cached=true", ""},
+		{"aborted", 0, func(c *Client) {
+			c.Hooks.BeforeRequest = func(context.Context, *Operation) error { return errors.New("denied") }
+		}, 1, list,
+			"ListIPs: mepost.operation=ListIPs, http.request.method=GET, mepost.retry_count=0", "denied"},
+	}
+	for _, tt := range tests {
+		var tenants []string
+		srv := flakyServer(tt.failures, &tenants)
+		c := NewClient("key")
+		c.BaseURL = srv.URL
+		c.Retry = RetryPolicy{MaxRetries: 1, MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+		tracer := &testTracer{}
+		c.Tracer = tracer
+		// The span's context is passed down the pipeline.
+		var nested []bool
+		c.Middleware = append(c.Middleware, func(next Handler) Handler {
+			return func(ctx context.Context, op *Operation, response interface{}) error {
+				nested = append(nested, ctx.Value(spanKey{}) != nil)
+				return next(ctx, op, response)
+			}
+		})
+		if tt.setup != nil {
+			tt.setup(c)
+		}
+		for i := 0; i < tt.calls; i++ {
+			tt.call(context.Background(), c)
+		}
+		srv.Close()
+
+		if len(tracer.spans) != tt.calls {
+			t.Fatalf("%s: started %d spans, want %d", tt.name, len(tracer.spans), tt.calls)
+		}
+		span := tracer.spans[tt.calls-1]
+		if got := span.name + ": " + strings.Join(span.attrs, ", "); got != tt.span {
+			t.Errorf("%s: got span\n%s\nwant\n%s", tt.name, got, tt.span)
+		}
+		if got := fmt.Sprint(span.err); (span.err != nil || tt.err != "") && got != tt.err {
+			t.Errorf("%s: span recorded error %s, want %q", tt.name, got, tt.err)
+		}
+		if !span.ended {
+			t.Errorf("%s: span was not ended", tt.name)
+		}
+		for i, ok := range nested {
+			if !ok {
+				t.Errorf("%s: call %d did not get the span's context", tt.name, i+1)
+			}
+		}
+	}
+}
+
+func TestErrorKind(t *testing.T) {
+	deadline, cancel := context.WithDeadline(context.Background(), time.Now())
+	defer cancel()
+	<-deadline.Done()
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{nil, ""},
+		{&APIError{StatusCode: 400}, "api"},
+		{fmt.Errorf("sending: %w", &APIError{StatusCode: 503}), "api"},
+		{ErrCircuitOpen, "circuit_open"},
+		{fmt.Errorf("listing IPs: %w", ErrCircuitOpen), "circuit_open"},
+		{ErrRateLimited, "rate_limited"},
+		{context.Canceled, "canceled"},
+		{deadline.Err(), "canceled"},
+		{&net.OpError{Op: "dial", Err: deadline.Err()}, "canceled"},
+		{&net.OpError{Op: "dial", Err: errors.New("connection refused")}, "transport"},
+		{errors.New("unexpected EOF"), "transport"},
+	}
+	for _, tt := range tests {
+		if got := ErrorKind(tt.err); got != tt.want {
+			t.Errorf("ErrorKind(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
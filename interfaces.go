@@ -0,0 +1,85 @@
+package mepost
+
+import "context"
+
+// The interfaces below describe the services of the client, e.g. GroupsServiceAPI
+// is implemented by client.Groups, so that code can depend on the narrowest set of
+// methods it needs. The Reader interfaces contain no mutating methods.
+
+// GroupsServiceReader retrieves email groups.
+type GroupsServiceReader interface {
+	List(ctx context.Context, opts *ListGroupsOptions) (*BaseResult[EmailGroup], error)
+	ListAll(ctx context.Context, opts *ListGroupsOptions) ([]EmailGroup, error)
+	Get(ctx context.Context, groupID string) (*EmailGroupWithCounts, error)
+}
+
+// GroupsServiceAPI manages email groups.
+type GroupsServiceAPI interface {
+	GroupsServiceReader
+	Create(ctx context.Context, request CreateNewGroupRequest) (*EmailGroup, error)
+	Update(ctx context.Context, groupID string, request RenameGroupRequest) (bool, error)
+	Delete(ctx context.Context, groupID string) (bool, error)
+}
+
+// SubscribersServiceReader retrieves the subscribers of email groups.
+type SubscribersServiceReader interface {
+	List(ctx context.Context, groupID string, opts *ListSubscribersOptions) (*BaseResult[Subscriber], error)
+	ListAll(ctx context.Context, groupID string, opts *ListSubscribersOptions) ([]Subscriber, error)
+	Get(ctx context.Context, groupID, email string) (*Subscriber, error)
+}
+
+// SubscribersServiceAPI manages the subscribers of email groups.
+type SubscribersServiceAPI interface {
+	SubscribersServiceReader
+	Create(ctx context.Context, groupID string, request CreateSubscriberRequest) (bool, error)
+	Delete(ctx context.Context, groupID string, request DeleteSubscriberRequest) (bool, error)
+}
+
+// MessagesServiceAPI sends messages.
+type MessagesServiceAPI interface {
+	SendTransactional(ctx context.Context, request SendTransactionalRequest) (*Schedule, error)
+	SendTransactionalTemplate(ctx context.Context, request SendMessageByTemplateRequest) (*Schedule, error)
+	SendMarketing(ctx context.Context, request SendMarketingRequest) (*Schedule, error)
+	SendMarketingTemplate(ctx context.Context, request SendMessageByTemplateRequest) (*Schedule, error)
+}
+
+// IPsServiceReader retrieves outbound IP addresses.
+type IPsServiceReader interface {
+	List(ctx context.Context) ([]IpAddress, error)
+	Get(ctx context.Context, ip string) (*IpAddress, error)
+}
+
+// IPsServiceAPI manages outbound IP addresses and their warm-ups.
+type IPsServiceAPI interface {
+	IPsServiceReader
+	SetGroup(ctx context.Context, request SetIpGroupRequest) (*SetIpGroupResponse, error)
+	StartWarmup(ctx context.Context, request StartWarmUpRequest) (*StartWarmUpResponse, error)
+	CancelWarmup(ctx context.Context, request CancelWarmUpRequest) (*CancelWarmUpResponse, error)
+}
+
+// IPGroupsServiceReader retrieves IP groups.
+type IPGroupsServiceReader interface {
+	List(ctx context.Context) ([]IPGroup, error)
+	Get(ctx context.Context, name string) (*IPGroup, error)
+}
+
+// IPGroupsServiceAPI manages IP groups.
+type IPGroupsServiceAPI interface {
+	IPGroupsServiceReader
+	Create(ctx context.Context, request CreateIpGroupRequest) (*IPGroup, error)
+}
+
+// DomainsServiceAPI manages the sending domains of the account.
+type DomainsServiceAPI interface {
+	Create(ctx context.Context, request AddDomainRequest) (*AddDomainResponse, error)
+	Delete(ctx context.Context, request RemoveDomainRequest) (*RemoveDomainResponse, error)
+}
+
+var (
+	_ GroupsServiceAPI      = (*GroupsService)(nil)
+	_ SubscribersServiceAPI = (*SubscribersService)(nil)
+	_ MessagesServiceAPI    = (*MessagesService)(nil)
+	_ IPsServiceAPI         = (*IPsService)(nil)
+	_ IPGroupsServiceAPI    = (*IPGroupsService)(nil)
+	_ DomainsServiceAPI     = (*DomainsService)(nil)
+)
@@ -0,0 +1,60 @@
+package mepost
+
+import (
+	"net/url"
+	"time"
+)
+
+// SortOrder is the direction in which list results are sorted.
+type SortOrder string
+
+// Sort orders.
+const (
+	SortAscending  SortOrder = "asc"
+	SortDescending SortOrder = "desc"
+)
+
+// TimeRange restricts a timestamp to an interval. Zero bounds are open.
+type TimeRange struct {
+	// After matches times at or after After.
+	After time.Time
+	// Before matches times strictly before Before.
+	Before time.Time
+}
+
+// contains reports whether t lies within the range.
+func (r TimeRange) contains(t time.Time) bool {
+	if !r.After.IsZero() && t.Before(r.After) {
+		return false
+	}
+	if !r.Before.IsZero() && !t.Before(r.Before) {
+		return false
+	}
+	return true
+}
+
+// setQuery adds the bounds of the range to query using the given parameter prefix.
+func (r TimeRange) setQuery(query url.Values, prefix string) {
+	if !r.After.IsZero() {
+		query.Set(prefix+"After", r.After.UTC().Format(time.RFC3339))
+	}
+	if !r.Before.IsZero() {
+		query.Set(prefix+"Before", r.Before.UTC().Format(time.RFC3339))
+	}
+}
+
+// lastPage reports whether a page of n results requested with the given limit
+// ends a listing, fetched results of which have been retrieved so far. total is
+// the total reported by the API; when it is zero, as when the API leaves it out,
+// only a short page ends the listing.
+func lastPage(n, limit, fetched, total int) bool {
+	return n < limit || (total > 0 && fetched >= total)
+}
+
+// less orders a and b according to order, which defaults to ascending.
+func less[T string | int | int64](a, b T, order SortOrder) bool {
+	if order == SortDescending {
+		return a > b
+	}
+	return a < b
+}
@@ -0,0 +1,171 @@
+package mepost
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var listEpoch = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+func day(n int) Time {
+	return Time{listEpoch.AddDate(0, 0, n)}
+}
+
+func groupNames(groups []EmailGroup) string {
+	names := make([]string, len(groups))
+	for i, g := range groups {
+		names[i] = g.Name
+	}
+	return strings.Join(names, ",")
+}
+
+func subscriberEmails(subscribers []Subscriber) string {
+	emails := make([]string, len(subscribers))
+	for i, s := range subscribers {
+		emails[i] = s.EmailAddress
+	}
+	return strings.Join(emails, ",")
+}
+
+func TestListGroupsApply(t *testing.T) {
+	groups := func() []EmailGroup {
+		return []EmailGroup{
+			{Name: "Newsletter", CreatedAt: day(2), UpdatedAt: day(5), TotalSubscriber: 30},
+			{Name: "alerts", CreatedAt: day(1), UpdatedAt: day(9), TotalSubscriber: 10},
+			{Name: "News EU", CreatedAt: day(3), UpdatedAt: day(3), TotalSubscriber: 20},
+			{Name: "beta", CreatedAt: day(0), UpdatedAt: day(1), TotalSubscriber: 20},
+		}
+	}
+	tests := []struct {
+		name string
+		opts ListGroupsOptions
+		want string
+	}{
+		{"none", ListGroupsOptions{}, "Newsletter,alerts,News EU,beta"},
+		{"search ignores case", ListGroupsOptions{Search: "NEWS"}, "Newsletter,News EU"},
+		{"created after is inclusive", ListGroupsOptions{Created: TimeRange{After: day(2).Time}}, "Newsletter,News EU"},
+		{"updated before is exclusive", ListGroupsOptions{Updated: TimeRange{Before: day(3).Time}}, "beta"},
+		{"name ignores case", ListGroupsOptions{SortBy: GroupSortByName}, "alerts,beta,News EU,Newsletter"},
+		{"created descending", ListGroupsOptions{SortBy: GroupSortByCreatedAt, Order: SortDescending}, "News EU,Newsletter,alerts,beta"},
+		{"updated", ListGroupsOptions{SortBy: GroupSortByUpdatedAt}, "beta,News EU,Newsletter,alerts"},
+		{"subscribers is stable", ListGroupsOptions{SortBy: GroupSortByTotalSubscriber}, "alerts,News EU,beta,Newsletter"},
+		{"filter and sort", ListGroupsOptions{Search: "e", SortBy: GroupSortByName, Order: SortDescending}, "Newsletter,News EU,beta,alerts"},
+	}
+	for _, tt := range tests {
+		if got := groupNames(tt.opts.apply(groups())); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestListSubscribersApply(t *testing.T) {
+	subscribers := func() []Subscriber {
+		return []Subscriber{
+			{EmailAddress: "carol@example.com", Confirmed: true, CreatedAt: day(2), SubscribedAt: day(4)},
+			{EmailAddress: "Alice@example.org", Confirmed: true, Unsubscribed: true, CreatedAt: day(0), SubscribedAt: day(1)},
+			{EmailAddress: "bob@example.com", Bounced: true, CreatedAt: day(1), SubscribedAt: day(6)},
+		}
+	}
+	tests := []struct {
+		name string
+		opts ListSubscribersOptions
+		want string
+	}{
+		{"none", ListSubscribersOptions{}, "carol@example.com,Alice@example.org,bob@example.com"},
+		{"confirmed", ListSubscribersOptions{Status: []SubscriberStatus{SubscriberConfirmed}}, "carol@example.com,Alice@example.org"},
+		{"unconfirmed", ListSubscribersOptions{Status: []SubscriberStatus{SubscriberUnconfirmed}}, "bob@example.com"},
+		{"any status", ListSubscribersOptions{Status: []SubscriberStatus{SubscriberUnsubscribed, SubscriberBounced}}, "Alice@example.org,bob@example.com"},
+		{"unknown status", ListSubscribersOptions{Status: []SubscriberStatus{"deleted"}}, ""},
+		{"email ignores case", ListSubscribersOptions{Email: "ALICE"}, "Alice@example.org"},
+		{"created range", ListSubscribersOptions{Created: TimeRange{After: day(1).Time, Before: day(2).Time}}, "bob@example.com"},
+		{"email", ListSubscribersOptions{SortBy: SubscriberSortByEmail}, "Alice@example.org,bob@example.com,carol@example.com"},
+		{"subscribed descending", ListSubscribersOptions{SortBy: SubscriberSortBySubscribedAt, Order: SortDescending}, "bob@example.com,carol@example.com,Alice@example.org"},
+		{"filter and sort", ListSubscribersOptions{Email: ".com", SortBy: SubscriberSortByCreatedAt}, "bob@example.com,carol@example.com"},
+	}
+	for _, tt := range tests {
+		if got := subscriberEmails(tt.opts.apply(subscribers())); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+// pagingServer serves n groups, or subscribers of group g1, by page. The total
+// is reported as total, or left out if total is negative.
+func pagingServer(t *testing.T, n, total int, pages *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		*pages = append(*pages, query.Get("page"))
+		limit, _ := strconv.Atoi(query.Get("limit"))
+		page, _ := strconv.Atoi(query.Get("page"))
+		var data []map[string]interface{}
+		for i := (page - 1) * limit; i < page*limit && i < n; i++ {
+			name := fmt.Sprintf("item%02d", n-i)
+			data = append(data, map[string]interface{}{"name": name, "emailAddress": name + "@example.com"})
+		}
+		result := map[string]interface{}{"data": data}
+		if total >= 0 {
+			result["total"] = total
+		}
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			t.Error(err)
+		}
+	}))
+}
+
+func TestListAllPaging(t *testing.T) {
+	tests := []struct {
+		name  string
+		n     int
+		total int
+		limit int
+		pages string
+	}{
+		{"total", 5, 5, 2, "1,2,3"},
+		{"total on a page boundary", 4, 4, 2, "1,2"},
+		{"total missing", 5, -1, 2, "1,2,3"},
+		{"total zero", 4, 0, 2, "1,2,3"},
+		{"empty", 0, 0, 2, "1"},
+		{"default limit", 150, -1, 0, "1,2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var pages []string
+			srv := pagingServer(t, tt.n, tt.total, &pages)
+			defer srv.Close()
+			c := NewClient("key")
+			c.BaseURL = srv.URL
+			ctx := context.Background()
+
+			groups, err := c.Groups.ListAll(ctx, &ListGroupsOptions{Limit: tt.limit, Page: 7, SortBy: GroupSortByName})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Join(pages, ","); got != tt.pages {
+				t.Errorf("groups: requested pages %s, want %s", got, tt.pages)
+			}
+			if len(groups) != tt.n {
+				t.Fatalf("got %d groups, want %d", len(groups), tt.n)
+			}
+			// The groups are sorted across pages.
+			if tt.n > 0 && groups[0].Name != "item01" {
+				t.Errorf("got first group %s, want item01", groups[0].Name)
+			}
+
+			pages = nil
+			subscribers, err := c.Subscribers.ListAll(ctx, "g1", &ListSubscribersOptions{Limit: tt.limit})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Join(pages, ","); got != tt.pages || len(subscribers) != tt.n {
+				t.Errorf("subscribers: requested pages %s for %d subscribers, want %s and %d", got, len(subscribers), tt.pages, tt.n)
+			}
+		})
+	}
+}
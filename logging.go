@@ -0,0 +1,174 @@
+package mepost
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// LogOptions controls how requests are logged to Client.Logger.
+//
+// Every HTTP request is logged with its operation, method, path, status,
+// duration and request ID. Request and response bodies are added when the
+// logger is enabled for slog.LevelDebug. The API key, DKIM private keys and
+// attachment contents are always redacted from logged bodies.
+type LogOptions struct {
+	// Level is the level of successful requests. If nil, slog.LevelInfo is used.
+	Level slog.Leveler
+	// ErrorLevel is the level of failed requests. If nil, slog.LevelError is used.
+	ErrorLevel slog.Leveler
+	// RedactEmails redacts recipient and subscriber email addresses from logged bodies and paths.
+	RedactEmails bool
+}
+
+// maxLoggedBody is the number of bytes of a body that are logged.
+const maxLoggedBody = 4096
+
+// requestIDHeaders are the response headers that may carry the ID the API assigned to a request.
+var requestIDHeaders = []string{"X-Request-Id", "Request-Id", "X-Correlation-Id"}
+
+// requestID returns the request ID of a response, or an empty string.
+func requestID(header http.Header) string {
+	for _, name := range requestIDHeaders {
+		if id := header.Get(name); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// logAttempt logs a single HTTP request. resp is nil if no response was received.
+func (c *Client) logAttempt(ctx context.Context, op *Operation, resp *http.Response, respBody []byte, err error, elapsed time.Duration) {
+	level := slog.LevelInfo
+	if c.LogOptions.Level != nil {
+		level = c.LogOptions.Level.Level()
+	}
+	failed := err != nil || resp.StatusCode < 200 || resp.StatusCode > 299
+	if failed {
+		level = slog.LevelError
+		if c.LogOptions.ErrorLevel != nil {
+			level = c.LogOptions.ErrorLevel.Level()
+		}
+	}
+	if !c.Logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("operation", op.Name),
+		slog.String("method", op.Method),
+		slog.String("path", c.logPath(op.URL)),
+		slog.Int("attempt", op.Attempts),
+		slog.Duration("duration", elapsed),
+	}
+	if resp != nil {
+		attrs = append(attrs, slog.Int("status", resp.StatusCode))
+		if id := requestID(resp.Header); id != "" {
+			attrs = append(attrs, slog.String("request_id", id))
+		}
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	if c.Logger.Enabled(ctx, slog.LevelDebug) {
+		if op.Request != nil {
+			// The request body is streamed to the API, so it is encoded again for the log.
+			if reqBody, err := c.codec().Marshal(op.Request); err == nil {
+				attrs = append(attrs, slog.String("request_body", c.redactBody(reqBody)))
+			}
+		}
+		if len(respBody) > 0 {
+			attrs = append(attrs, slog.String("response_body", c.redactBody(respBody)))
+		}
+	}
+	c.Logger.LogAttrs(ctx, level, "mepost request", attrs...)
+}
+
+// logPath returns the path and query of rawURL, with email addresses redacted if configured.
+func (c *Client) logPath(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	if !c.LogOptions.RedactEmails {
+		return u.RequestURI()
+	}
+	segments := strings.Split(u.EscapedPath(), "/")
+	for i, segment := range segments {
+		if unescaped, err := url.PathUnescape(segment); err == nil && strings.Contains(unescaped, "@") {
+			segments[i] = redacted
+		}
+	}
+	path := strings.Join(segments, "/")
+	if u.RawQuery == "" {
+		return path
+	}
+	query := u.Query()
+	for key, values := range query {
+		for i, value := range values {
+			if strings.Contains(value, "@") {
+				query[key][i] = redacted
+			}
+		}
+	}
+	return path + "?" + query.Encode()
+}
+
+// redactBody returns a JSON body with sensitive values replaced, truncated to maxLoggedBody.
+func (c *Client) redactBody(body []byte) string {
+	var v interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&v); err == nil {
+		v = c.redactValue("", v)
+		if data, err := json.Marshal(v); err == nil {
+			body = data
+		}
+	} else {
+		body = bytes.TrimSpace(body)
+	}
+	s := string(body)
+	if key := c.APIKey.Value(); key != "" {
+		s = strings.ReplaceAll(s, key, redacted)
+	}
+	if len(s) > maxLoggedBody {
+		s = s[:maxLoggedBody] + fmt.Sprintf("...(%d bytes)", len(body))
+	}
+	return s
+}
+
+// redactValue redacts a decoded JSON value found under key. Elements of arrays
+// are redacted as if found under the array's key. The recipient keys are those
+// redacted by the recorder package: to, email, emails and emailAddress.
+func (c *Client) redactValue(key string, v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, value := range v {
+			v[k] = c.redactValue(k, value)
+		}
+		return v
+	case []interface{}:
+		for i, value := range v {
+			v[i] = c.redactValue(key, value)
+		}
+		return v
+	case string:
+		switch strings.ToLower(key) {
+		case "dkimprivatekey", "apikey", "password", "token":
+			return redacted
+		case "base64content":
+			return fmt.Sprintf("[REDACTED %d bytes]", len(v))
+		case "to", "email", "emails", "emailaddress":
+			if c.LogOptions.RedactEmails {
+				return redacted
+			}
+		}
+	}
+	return v
+}
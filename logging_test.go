@@ -0,0 +1,31 @@
+package mepost
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRedactBodyEmails(t *testing.T) {
+	c := &Client{APIKey: "secret-key", LogOptions: LogOptions{RedactEmails: true}}
+	bodies := []string{
+		`{"to":["alice@example.com","bob@example.com"],"subject":"Hi"}`,
+		`{"to":[{"email":"alice@example.com","name":"Alice"}]}`,
+		`{"emails":["alice@example.com"]}`,
+		`{"data":[{"emailAddress":"alice@example.com","uuid":"1"}],"total":1}`,
+		`{"EmailAddress":"alice@example.com"}`,
+	}
+	for _, body := range bodies {
+		got := c.redactBody([]byte(body))
+		if strings.Contains(got, "@example.com") {
+			t.Errorf("redactBody(%s) = %s, want addresses redacted", body, got)
+		}
+	}
+
+	c.LogOptions.RedactEmails = false
+	if got := c.redactBody([]byte(bodies[0])); !strings.Contains(got, "alice@example.com") {
+		t.Errorf("redactBody redacted addresses without RedactEmails: %s", got)
+	}
+	if got := c.redactBody([]byte(`{"apikey":"secret-key","note":"secret-key"}`)); strings.Contains(got, "secret-key") {
+		t.Errorf("redactBody kept the API key: %s", got)
+	}
+}
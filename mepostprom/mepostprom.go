@@ -0,0 +1,199 @@
+// Package mepostprom exposes Mepost client metrics in the Prometheus text
+// exposition format, without depending on the Prometheus client library.
+//
+//	metrics := mepostprom.New()
+//	client.Metrics = metrics
+//	http.Handle("/metrics", metrics)
+//
+// The following metrics are exported:
+//
+//	mepost_operations_total{operation, status}       completed operations by HTTP status ("none" if no response)
+//	mepost_operation_errors_total{operation, kind}   failed operations by mepost.ErrorKind
+//	mepost_operation_retries_total{operation}        retried requests
+//	mepost_operation_duration_seconds{operation}     operation latency, including retries
+//	mepost_cache_hits_total{operation}               results served by Client.Cache
+//
+// Results served by the cache are only counted by mepost_cache_hits_total.
+package mepostprom
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	mepost "github.com/mepost-io/golang-sdk"
+)
+
+// DefaultBuckets are the latency histogram buckets, in seconds, used by New.
+var DefaultBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// Metrics implements mepost.Metrics and serves the collected metrics over HTTP.
+type Metrics struct {
+	buckets []float64
+
+	mu         sync.Mutex
+	operations map[[2]string]uint64
+	errors     map[[2]string]uint64
+	retries    map[string]uint64
+	durations  map[string]*histogram
+	cacheHits  map[string]uint64
+}
+
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// New creates a Metrics with the given latency buckets in seconds, or DefaultBuckets if none are given.
+func New(buckets ...float64) *Metrics {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	return &Metrics{
+		buckets:    buckets,
+		operations: map[[2]string]uint64{},
+		errors:     map[[2]string]uint64{},
+		retries:    map[string]uint64{},
+		durations:  map[string]*histogram{},
+		cacheHits:  map[string]uint64{},
+	}
+}
+
+var (
+	_ mepost.Metrics      = (*Metrics)(nil)
+	_ mepost.CacheMetrics = (*Metrics)(nil)
+)
+
+// RecordOperation implements mepost.Metrics.
+func (m *Metrics) RecordOperation(operation string, status int, err error, duration time.Duration, retries int) {
+	statusLabel := "none"
+	if status != 0 {
+		statusLabel = strconv.Itoa(status)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.operations[[2]string{operation, statusLabel}]++
+	if err != nil {
+		m.errors[[2]string{operation, mepost.ErrorKind(err)}]++
+	}
+	if retries > 0 {
+		m.retries[operation] += uint64(retries)
+	}
+	h := m.durations[operation]
+	if h == nil {
+		h = &histogram{counts: make([]uint64, len(m.buckets))}
+		m.durations[operation] = h
+	}
+	seconds := duration.Seconds()
+	for i, bound := range m.buckets {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += seconds
+	h.count++
+}
+
+// RecordCacheHit implements mepost.CacheMetrics.
+func (m *Metrics) RecordCacheHit(operation string, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cacheHits[operation]++
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var b strings.Builder
+
+	writeHeader(&b, "mepost_operations_total", "counter", "Completed Mepost API operations by HTTP status.")
+	for _, key := range sortedPairs(m.operations) {
+		fmt.Fprintf(&b, "mepost_operations_total{operation=%s,status=%s} %d\n", quote(key[0]), quote(key[1]), m.operations[key])
+	}
+
+	writeHeader(&b, "mepost_operation_errors_total", "counter", "Failed Mepost API operations by error kind.")
+	for _, key := range sortedPairs(m.errors) {
+		fmt.Fprintf(&b, "mepost_operation_errors_total{operation=%s,kind=%s} %d\n", quote(key[0]), quote(key[1]), m.errors[key])
+	}
+
+	writeHeader(&b, "mepost_operation_retries_total", "counter", "Retried Mepost API requests.")
+	for _, operation := range sortedKeys(m.retries) {
+		fmt.Fprintf(&b, "mepost_operation_retries_total{operation=%s} %d\n", quote(operation), m.retries[operation])
+	}
+
+	writeHeader(&b, "mepost_operation_duration_seconds", "histogram", "Latency of Mepost API operations, including retries.")
+	for _, operation := range sortedKeys(m.durations) {
+		h := m.durations[operation]
+		for i, bound := range m.buckets {
+			fmt.Fprintf(&b, "mepost_operation_duration_seconds_bucket{operation=%s,le=%s} %d\n", quote(operation), quote(formatFloat(bound)), h.counts[i])
+		}
+		fmt.Fprintf(&b, "mepost_operation_duration_seconds_bucket{operation=%s,le=\"+Inf\"} %d\n", quote(operation), h.count)
+		fmt.Fprintf(&b, "mepost_operation_duration_seconds_sum{operation=%s} %s\n", quote(operation), formatFloat(h.sum))
+		fmt.Fprintf(&b, "mepost_operation_duration_seconds_count{operation=%s} %d\n", quote(operation), h.count)
+	}
+
+	writeHeader(&b, "mepost_cache_hits_total", "counter", "Mepost API results served by the client cache.")
+	for _, operation := range sortedKeys(m.cacheHits) {
+		fmt.Fprintf(&b, "mepost_cache_hits_total{operation=%s} %d\n", quote(operation), m.cacheHits[operation])
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+func writeHeader(b *strings.Builder, name, kind, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedPairs(m map[[2]string]uint64) [][2]string {
+	keys := make([][2]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+	return keys
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// quote quotes a label value.
+func quote(value string) string {
+	return `"` + labelEscaper.Replace(value) + `"`
+}
+
+func formatFloat(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
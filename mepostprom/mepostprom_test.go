@@ -0,0 +1,69 @@
+package mepostprom
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	mepost "github.com/mepost-io/golang-sdk"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func TestWriteTo(t *testing.T) {
+	// The buckets are sorted, and bounds are inclusive.
+	m := New(1, 0.25, 0.5)
+	m.RecordOperation("ListIPs", 200, nil, 250*time.Millisecond, 0)
+	m.RecordOperation("ListIPs", 200, nil, 500*time.Millisecond, 2)
+	m.RecordOperation("ListIPs", 503, &mepost.APIError{StatusCode: 503}, 2*time.Second, 1)
+	m.RecordOperation("SendTransactional", 0, mepost.ErrCircuitOpen, 0, 0)
+	m.RecordOperation("SendTransactional", 0, mepost.ErrRateLimited, 0, 0)
+	m.RecordOperation("SendTransactional", 0, context.Canceled, 40*time.Second, 0)
+	m.RecordOperation("SendTransactional", 0, errors.New("connection reset"), time.Second, 0)
+	// Label values are escaped.
+	m.RecordOperation("Do \"raw\"\\\npath", 200, nil, 0, 0)
+	m.RecordCacheHit("ListIPGroups", time.Millisecond)
+	m.RecordCacheHit("ListIPGroups", time.Millisecond)
+
+	var b strings.Builder
+	n, err := m.WriteTo(&b)
+	if err != nil || n != int64(b.Len()) {
+		t.Fatalf("WriteTo returned %d, %v for %d bytes", n, err, b.Len())
+	}
+	golden := filepath.Join("testdata", "metrics.txt")
+	if *update {
+		if err := os.WriteFile(golden, []byte(b.String()), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != string(want) {
+		t.Errorf("got\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		value, want string
+	}{
+		{"ListIPs", `"ListIPs"`},
+		{"", `""`},
+		{`say "hi"`, `"say \"hi\""`},
+		{`C:\path`, `"C:\\path"`},
+		{"two\nlines", `"two\nlines"`},
+		{"tab\tand é", "\"tab\tand é\""},
+	}
+	for _, tt := range tests {
+		if got := quote(tt.value); got != tt.want {
+			t.Errorf("quote(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
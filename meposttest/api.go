@@ -0,0 +1,659 @@
+package meposttest
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	mepost "github.com/mepost-io/golang-sdk"
+)
+
+// SentMessage is a message accepted by one of the send endpoints.
+type SentMessage struct {
+	// Endpoint is the send endpoint that was called, e.g. "transactional" or
+	// "marketing-by-template".
+	Endpoint string
+	// Transactional reports whether the message was sent through a transactional endpoint.
+	Transactional bool
+	// TemplateID is set for messages sent by template.
+	TemplateID    string
+	FromEmail     string
+	FromName      string
+	Subject       string
+	Html          string
+	Text          string
+	ReturnPath    string
+	IpGroup       string
+	To            []mepost.To
+	Headers       map[string]string
+	Customization map[string]string
+	Attachments   []mepost.AttachmentDto
+	ScheduledAt   time.Time
+	// Schedule is the schedule returned to the client.
+	Schedule mepost.Schedule
+}
+
+// Recipients returns the email addresses the message was sent to.
+func (m SentMessage) Recipients() []string {
+	emails := make([]string, len(m.To))
+	for i, to := range m.To {
+		emails[i] = to.Email
+	}
+	return emails
+}
+
+type group struct {
+	id          int
+	group       mepost.EmailGroup
+	subscribers []*mepost.Subscriber
+}
+
+func (s *Server) route(method string, path []string, query url.Values, body []byte) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case match(path, "company", "domain", "add") && method == http.MethodPost:
+		return s.addDomain(body)
+	case match(path, "company", "domain", "remove") && method == http.MethodDelete:
+		return s.removeDomain(body)
+
+	case match(path, "groups") && method == http.MethodGet:
+		return s.listGroups(query)
+	case match(path, "groups") && method == http.MethodPost:
+		return s.createGroup(body)
+	case match(path, "groups", "*") && method == http.MethodGet:
+		return s.getGroup(path[1])
+	case match(path, "groups", "*") && method == http.MethodPut:
+		return s.updateGroup(path[1], body)
+	case match(path, "groups", "*") && method == http.MethodDelete:
+		return s.deleteGroup(path[1])
+	case match(path, "groups", "*", "subscribers") && method == http.MethodGet:
+		return s.listSubscribers(path[1], query)
+	case match(path, "groups", "*", "subscribers") && method == http.MethodPost:
+		return s.addSubscribers(path[1], body)
+	case match(path, "groups", "*", "subscribers") && method == http.MethodDelete:
+		return s.deleteSubscribers(path[1], body)
+	case match(path, "groups", "*", "subscribers", "*") && method == http.MethodGet:
+		return s.getSubscriber(path[1], path[3])
+
+	case match(path, "messages", "*") && method == http.MethodPost:
+		return s.send(path[1], body)
+
+	case match(path, "outbound", "ip-group", "create") && method == http.MethodPost:
+		return s.createIpGroup(body)
+	case match(path, "outbound", "ip-group", "info", "*") && method == http.MethodGet:
+		return s.getIpGroup(path[3])
+	case match(path, "outbound", "ip-group", "list") && method == http.MethodGet:
+		return s.listIpGroups(), nil
+	case match(path, "outbound", "ip", "cancel-warmup") && method == http.MethodPost:
+		return s.cancelWarmup(body)
+	case match(path, "outbound", "ip", "info", "*") && method == http.MethodGet:
+		return s.getIp(path[3])
+	case match(path, "outbound", "ip", "list") && method == http.MethodGet:
+		return s.listIps(), nil
+	case match(path, "outbound", "ip", "set-ip-group") && method == http.MethodPost:
+		return s.setIpGroup(body)
+	case match(path, "outbound", "ip", "start-warmup") && method == http.MethodPost:
+		return s.startWarmup(body)
+	}
+	return nil, errorf(http.StatusNotFound, "no route for %s /%s", method, strings.Join(path, "/"))
+}
+
+// match reports whether path has the given segments; "*" matches any single segment.
+func match(path []string, segments ...string) bool {
+	if len(path) != len(segments) {
+		return false
+	}
+	for i, segment := range segments {
+		if segment != "*" && segment != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func now() mepost.Time {
+	return mepost.Time{Time: time.Now().UTC()}
+}
+
+// paginate returns the bounds of the requested page of n items.
+func paginate(query url.Values, n int) (start, end int) {
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 10
+	}
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil || page <= 0 {
+		page = 1
+	}
+	start = (page - 1) * limit
+	if start > n {
+		start = n
+	}
+	end = start + limit
+	if end > n {
+		end = n
+	}
+	return start, end
+}
+
+func (s *Server) addDomain(body []byte) (interface{}, error) {
+	var req mepost.AddDomainRequest
+	if err := decode(body, &req); err != nil {
+		return nil, err
+	}
+	if req.Domain == "" {
+		return nil, errorf(http.StatusBadRequest, "domain is required")
+	}
+	if _, ok := s.domains[req.Domain]; ok {
+		return nil, errorf(http.StatusConflict, "domain %s already exists", req.Domain)
+	}
+	resp := &mepost.AddDomainResponse{
+		Domain: req.Domain,
+		DKIM: mepost.DNSRecord{
+			Name:    "mepost._domainkey." + req.Domain,
+			Type:    "TXT",
+			Content: "v=DKIM1; k=rsa; p=meposttest",
+		},
+		DMARC: mepost.DNSRecord{
+			Name:    "_dmarc." + req.Domain,
+			Type:    "TXT",
+			Content: "v=DMARC1; p=none",
+		},
+		SPF: mepost.DNSRecord{
+			Name:    req.Domain,
+			Type:    "TXT",
+			Content: "v=spf1 include:spf.mepost.io ~all",
+		},
+	}
+	s.domains[req.Domain] = resp
+	return resp, nil
+}
+
+func (s *Server) removeDomain(body []byte) (interface{}, error) {
+	var req mepost.RemoveDomainRequest
+	if err := decode(body, &req); err != nil {
+		return nil, err
+	}
+	if _, ok := s.domains[req.Domain]; !ok {
+		return nil, errorf(http.StatusNotFound, "domain %s not found", req.Domain)
+	}
+	delete(s.domains, req.Domain)
+	return &mepost.RemoveDomainResponse{Domain: req.Domain, RemovedAt: now()}, nil
+}
+
+func (s *Server) findGroup(id string) (*group, error) {
+	for _, g := range s.groups {
+		if g.group.UUID == id {
+			return g, nil
+		}
+	}
+	return nil, errorf(http.StatusNotFound, "group %s not found", id)
+}
+
+// refreshCounts updates the subscriber totals of g.
+func (g *group) refreshCounts() {
+	active, unsubscribed := 0, 0
+	for _, sub := range g.subscribers {
+		if sub.Unsubscribed {
+			unsubscribed++
+		} else {
+			active++
+		}
+	}
+	g.group.TotalSubscriber = len(g.subscribers)
+	g.group.TotalActiveSubscriber = active
+	g.group.TotalUnsubscribe = unsubscribed
+}
+
+func (s *Server) listGroups(query url.Values) (interface{}, error) {
+	start, end := paginate(query, len(s.groups))
+	result := &mepost.BaseResult[mepost.EmailGroup]{Data: []mepost.EmailGroup{}, Total: len(s.groups)}
+	for _, g := range s.groups[start:end] {
+		result.Data = append(result.Data, g.group)
+	}
+	return result, nil
+}
+
+func (s *Server) createGroup(body []byte) (interface{}, error) {
+	var req mepost.CreateNewGroupRequest
+	if err := decode(body, &req); err != nil {
+		return nil, err
+	}
+	if req.Name == "" {
+		return nil, errorf(http.StatusBadRequest, "name is required")
+	}
+	s.nextID++
+	g := &group{
+		id: s.nextID,
+		group: mepost.EmailGroup{
+			CompanyId: 1,
+			CreatedAt: now(),
+			Name:      req.Name,
+			UpdatedAt: now(),
+			UUID:      newUUID(),
+		},
+	}
+	g.addSubscribers(req.To)
+	s.groups = append(s.groups, g)
+	return &g.group, nil
+}
+
+func (s *Server) getGroup(id string) (interface{}, error) {
+	g, err := s.findGroup(id)
+	if err != nil {
+		return nil, err
+	}
+	bounced := 0
+	for _, sub := range g.subscribers {
+		if sub.Bounced {
+			bounced++
+		}
+	}
+	return &mepost.EmailGroupWithCounts{
+		CompanyId:             g.group.CompanyId,
+		CreatedAt:             g.group.CreatedAt,
+		GeneralScore:          g.group.GeneralScore,
+		IsWeb:                 g.group.IsWeb,
+		Name:                  g.group.Name,
+		NewsletterScore:       g.group.NewsletterScore,
+		Priority:              g.group.Priority,
+		TotalActiveSubscriber: g.group.TotalActiveSubscriber,
+		TotalBounced:          bounced,
+		TotalSubscriber:       g.group.TotalSubscriber,
+		TotalUnsubscribe:      g.group.TotalUnsubscribe,
+		UpdatedAt:             g.group.UpdatedAt,
+		UUID:                  g.group.UUID,
+	}, nil
+}
+
+func (s *Server) updateGroup(id string, body []byte) (interface{}, error) {
+	var req mepost.RenameGroupRequest
+	if err := decode(body, &req); err != nil {
+		return nil, err
+	}
+	g, err := s.findGroup(id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Name == "" {
+		return nil, errorf(http.StatusBadRequest, "name is required")
+	}
+	g.group.Name = req.Name
+	g.group.UpdatedAt = now()
+	return true, nil
+}
+
+func (s *Server) deleteGroup(id string) (interface{}, error) {
+	for i, g := range s.groups {
+		if g.group.UUID == id {
+			s.groups = append(s.groups[:i], s.groups[i+1:]...)
+			return true, nil
+		}
+	}
+	return nil, errorf(http.StatusNotFound, "group %s not found", id)
+}
+
+func (g *group) addSubscribers(to []mepost.To) {
+	for _, recipient := range to {
+		var sub *mepost.Subscriber
+		for _, existing := range g.subscribers {
+			if strings.EqualFold(existing.EmailAddress, recipient.Email) {
+				sub = existing
+			}
+		}
+		if sub == nil {
+			sub = &mepost.Subscriber{
+				Confirmed:    true,
+				CreatedAt:    now(),
+				EmailAddress: recipient.Email,
+				EmailGroupId: g.id,
+				SubscribedAt: now(),
+				UUID:         newUUID(),
+			}
+			g.subscribers = append(g.subscribers, sub)
+		}
+		names := make([]string, 0, len(recipient.Customization))
+		for name := range recipient.Customization {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		sub.CustomFields = nil
+		for _, name := range names {
+			sub.CustomFields = append(sub.CustomFields, mepost.CustomField{Name: name, Value: recipient.Customization[name]})
+		}
+		sub.UpdatedAt = now()
+	}
+	g.refreshCounts()
+}
+
+func (s *Server) listSubscribers(id string, query url.Values) (interface{}, error) {
+	g, err := s.findGroup(id)
+	if err != nil {
+		return nil, err
+	}
+	start, end := paginate(query, len(g.subscribers))
+	result := &mepost.BaseResult[mepost.Subscriber]{Data: []mepost.Subscriber{}, Total: len(g.subscribers)}
+	for _, sub := range g.subscribers[start:end] {
+		result.Data = append(result.Data, *sub)
+	}
+	return result, nil
+}
+
+func (s *Server) addSubscribers(id string, body []byte) (interface{}, error) {
+	var req mepost.CreateSubscriberRequest
+	if err := decode(body, &req); err != nil {
+		return nil, err
+	}
+	g, err := s.findGroup(id)
+	if err != nil {
+		return nil, err
+	}
+	for _, to := range req.To {
+		if to.Email == "" {
+			return nil, errorf(http.StatusBadRequest, "email is required")
+		}
+	}
+	g.addSubscribers(req.To)
+	return true, nil
+}
+
+func (s *Server) deleteSubscribers(id string, body []byte) (interface{}, error) {
+	var req mepost.DeleteSubscriberRequest
+	if err := decode(body, &req); err != nil {
+		return nil, err
+	}
+	g, err := s.findGroup(id)
+	if err != nil {
+		return nil, err
+	}
+	kept := g.subscribers[:0]
+	for _, sub := range g.subscribers {
+		remove := false
+		for _, email := range req.Emails {
+			if strings.EqualFold(sub.EmailAddress, email) {
+				remove = true
+			}
+		}
+		if !remove {
+			kept = append(kept, sub)
+		}
+	}
+	g.subscribers = kept
+	g.refreshCounts()
+	return true, nil
+}
+
+func (s *Server) getSubscriber(id, email string) (interface{}, error) {
+	g, err := s.findGroup(id)
+	if err != nil {
+		return nil, err
+	}
+	for _, sub := range g.subscribers {
+		if strings.EqualFold(sub.EmailAddress, email) {
+			return sub, nil
+		}
+	}
+	return nil, errorf(http.StatusNotFound, "subscriber %s not found", email)
+}
+
+func (s *Server) send(endpoint string, body []byte) (interface{}, error) {
+	msg := SentMessage{Endpoint: endpoint}
+	var scheduledAt *mepost.ScheduledTime
+	switch endpoint {
+	case "transactional":
+		var req mepost.SendTransactionalRequest
+		if err := decode(body, &req); err != nil {
+			return nil, err
+		}
+		msg.Transactional = true
+		msg.fill(mepost.MessageDto(req))
+		scheduledAt = req.ScheduledAt
+	case "marketing":
+		var req mepost.SendMarketingRequest
+		if err := decode(body, &req); err != nil {
+			return nil, err
+		}
+		dto := mepost.MessageDto{
+			Attachments:   req.Attachments,
+			Customization: req.Customization,
+			FromEmail:     req.FromEmail,
+			FromName:      req.FromName,
+			Headers:       req.Headers,
+			Html:          req.Html,
+			IpGroup:       req.IpGroup,
+			ReturnPath:    req.ReturnPath,
+			Subject:       req.Subject,
+			Text:          req.Text,
+		}
+		for _, email := range req.To {
+			dto.To = append(dto.To, mepost.To{Email: email})
+		}
+		msg.fill(dto)
+		scheduledAt = req.ScheduledAt
+	case "transactional-by-template", "marketing-by-template":
+		var req mepost.SendMessageByTemplateRequest
+		if err := decode(body, &req); err != nil {
+			return nil, err
+		}
+		if req.TemplateID == "" {
+			return nil, errorf(http.StatusBadRequest, "templateId is required")
+		}
+		msg.Transactional = endpoint == "transactional-by-template"
+		msg.TemplateID = req.TemplateID
+		msg.fill(req.Message)
+		scheduledAt = req.Message.ScheduledAt
+	default:
+		return nil, errorf(http.StatusNotFound, "no route for POST /messages/%s", endpoint)
+	}
+	if msg.FromEmail == "" {
+		return nil, errorf(http.StatusBadRequest, "fromEmail is required")
+	}
+	if msg.Subject == "" && msg.TemplateID == "" {
+		return nil, errorf(http.StatusBadRequest, "subject is required")
+	}
+	if len(msg.To) == 0 {
+		return nil, errorf(http.StatusBadRequest, "at least one recipient is required")
+	}
+
+	schedule := mepost.Schedule{
+		Approved:         true,
+		AuthorizedToSend: true,
+		CreatedAt:        now(),
+		CreditAmount:     float64(len(msg.To)),
+		JobStatus:        mepost.JobStatusCompleted,
+		JobType:          mepost.JobTypeMarketing,
+		ScheduledAt:      now(),
+		StatId:           newUUID(),
+		UpdatedAt:        now(),
+		UUID:             newUUID(),
+	}
+	if msg.Transactional {
+		schedule.JobType = mepost.JobTypeTransactional
+	}
+	if scheduledAt != nil && !scheduledAt.IsZero() {
+		msg.ScheduledAt = scheduledAt.Time
+		schedule.ScheduledAt = mepost.Time{Time: scheduledAt.Time}
+		schedule.JobStatus = mepost.JobStatusScheduled
+	}
+	schedule.Template.UUID = msg.TemplateID
+	msg.Schedule = schedule
+	s.sent = append(s.sent, msg)
+	return &schedule, nil
+}
+
+func (m *SentMessage) fill(dto mepost.MessageDto) {
+	m.FromEmail = dto.FromEmail
+	m.FromName = dto.FromName
+	m.Subject = dto.Subject
+	m.Html = dto.Html
+	m.Text = dto.Text
+	m.ReturnPath = dto.ReturnPath
+	m.IpGroup = dto.IpGroup
+	m.To = dto.To
+	m.Headers = dto.Headers
+	m.Customization = dto.Customization
+	m.Attachments = dto.Attachments
+}
+
+func (s *Server) findIpGroup(name string) (*mepost.IPGroup, error) {
+	for _, g := range s.ipGroups {
+		if g.Name == name {
+			return g, nil
+		}
+	}
+	return nil, errorf(http.StatusNotFound, "IP group %s not found", name)
+}
+
+func (s *Server) findIp(ip string) (*mepost.IpAddress, error) {
+	for _, address := range s.ips {
+		if address.Ip == ip {
+			return address, nil
+		}
+	}
+	return nil, errorf(http.StatusNotFound, "IP address %s not found", ip)
+}
+
+// ipGroupView returns a copy of g with its current IP addresses.
+func (s *Server) ipGroupView(g *mepost.IPGroup, id int) mepost.IPGroup {
+	view := *g
+	view.IpAddresses = []mepost.IpAddress{}
+	for _, address := range s.ips {
+		if address.IpGroupId == id {
+			view.IpAddresses = append(view.IpAddresses, *address)
+		}
+	}
+	return view
+}
+
+func (s *Server) ipGroupID(name string) int {
+	for i, g := range s.ipGroups {
+		if g.Name == name {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+func (s *Server) createIpGroup(body []byte) (interface{}, error) {
+	var req mepost.CreateIpGroupRequest
+	if err := decode(body, &req); err != nil {
+		return nil, err
+	}
+	if req.GroupName == "" {
+		return nil, errorf(http.StatusBadRequest, "groupName is required")
+	}
+	if _, err := s.findIpGroup(req.GroupName); err == nil {
+		return nil, errorf(http.StatusConflict, "IP group %s already exists", req.GroupName)
+	}
+	g := &mepost.IPGroup{
+		CompanyId: 1,
+		CreatedAt: now(),
+		Name:      req.GroupName,
+		UpdatedAt: now(),
+		UUID:      newUUID(),
+	}
+	s.ipGroups = append(s.ipGroups, g)
+	view := s.ipGroupView(g, len(s.ipGroups))
+	return &view, nil
+}
+
+func (s *Server) getIpGroup(name string) (interface{}, error) {
+	g, err := s.findIpGroup(name)
+	if err != nil {
+		return nil, err
+	}
+	view := s.ipGroupView(g, s.ipGroupID(name))
+	return &view, nil
+}
+
+func (s *Server) listIpGroups() interface{} {
+	groups := []mepost.IPGroup{}
+	for i, g := range s.ipGroups {
+		groups = append(groups, s.ipGroupView(g, i+1))
+	}
+	return groups
+}
+
+func (s *Server) getIp(ip string) (interface{}, error) {
+	return s.findIp(ip)
+}
+
+func (s *Server) listIps() interface{} {
+	ips := []mepost.IpAddress{}
+	for _, address := range s.ips {
+		ips = append(ips, *address)
+	}
+	return ips
+}
+
+func (s *Server) setIpGroup(body []byte) (interface{}, error) {
+	var req mepost.SetIpGroupRequest
+	if err := decode(body, &req); err != nil {
+		return nil, err
+	}
+	address, err := s.findIp(req.IpAddress)
+	if err != nil {
+		return nil, err
+	}
+	g, err := s.findIpGroup(req.GroupName)
+	if err != nil {
+		return nil, err
+	}
+	id := s.ipGroupID(req.GroupName)
+	address.IpGroupId = id
+	address.UpdatedAt = now()
+	return &mepost.SetIpGroupResponse{IpAddress: address.Ip, IpGroup: s.ipGroupView(g, id)}, nil
+}
+
+func (s *Server) startWarmup(body []byte) (interface{}, error) {
+	var req mepost.StartWarmUpRequest
+	if err := decode(body, &req); err != nil {
+		return nil, err
+	}
+	address, err := s.findIp(req.IpAddress)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := s.warmups[address.Ip]; ok {
+		return nil, errorf(http.StatusConflict, "IP address %s is already warming up", address.Ip)
+	}
+	start := now()
+	warmup := &mepost.StartWarmUpResponse{
+		IPAddress: address.Ip,
+		StartAt:   start,
+		EndAt:     mepost.Time{Time: start.Add(30 * 24 * time.Hour)},
+		Status:    mepost.IPStatusWarmingUp,
+	}
+	s.warmups[address.Ip] = warmup
+	address.Status = mepost.IPStatusWarmingUp
+	address.UpdatedAt = start
+	return warmup, nil
+}
+
+func (s *Server) cancelWarmup(body []byte) (interface{}, error) {
+	var req mepost.CancelWarmUpRequest
+	if err := decode(body, &req); err != nil {
+		return nil, err
+	}
+	address, err := s.findIp(req.IpAddress)
+	if err != nil {
+		return nil, err
+	}
+	warmup, ok := s.warmups[address.Ip]
+	if !ok {
+		return nil, errorf(http.StatusConflict, "IP address %s is not warming up", address.Ip)
+	}
+	delete(s.warmups, address.Ip)
+	address.Status = mepost.IPStatusActive
+	address.UpdatedAt = now()
+	return &mepost.CancelWarmUpResponse{
+		CancelledAt: now(),
+		IPAddress:   address.Ip,
+		StartedAt:   warmup.StartAt,
+	}, nil
+}
@@ -0,0 +1,223 @@
+package meposttest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	mepost "github.com/mepost-io/golang-sdk"
+)
+
+// wantStatus fails the test unless err is an APIError with the given status.
+func wantStatus(t *testing.T, err error, status int) {
+	t.Helper()
+	var apiErr *mepost.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != status {
+		t.Errorf("got error %v, want status %d", err, status)
+	}
+}
+
+func TestGroupRoutes(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	client := srv.Client()
+	ctx := context.Background()
+
+	var ids []string
+	for i := 1; i <= 3; i++ {
+		g, err := client.Groups.Create(ctx, mepost.CreateNewGroupRequest{
+			Name: fmt.Sprint("group ", i),
+			To:   []mepost.To{{Email: "alice@example.com"}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if g.UUID == "" || g.TotalSubscriber != 1 || g.CreatedAt.IsZero() {
+			t.Errorf("created group %+v", g)
+		}
+		ids = append(ids, g.UUID)
+	}
+	_, err := client.Groups.Create(ctx, mepost.CreateNewGroupRequest{})
+	wantStatus(t, err, http.StatusBadRequest)
+
+	page, err := client.Groups.List(ctx, &mepost.ListGroupsOptions{Limit: 2, Page: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 3 || len(page.Data) != 1 || page.Data[0].Name != "group 3" {
+		t.Errorf("got page 2 %+v, want group 3 of 3", page)
+	}
+
+	if ok, err := client.Groups.Update(ctx, ids[0], mepost.RenameGroupRequest{Name: "renamed"}); err != nil || !ok {
+		t.Fatalf("update: %v, %v", ok, err)
+	}
+	_, err = client.Groups.Update(ctx, ids[0], mepost.RenameGroupRequest{})
+	wantStatus(t, err, http.StatusBadRequest)
+	_, err = client.Groups.Update(ctx, "missing", mepost.RenameGroupRequest{Name: "x"})
+	wantStatus(t, err, http.StatusNotFound)
+	g, err := client.Groups.Get(ctx, ids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Name != "renamed" || g.UUID != ids[0] || g.TotalSubscriber != 1 {
+		t.Errorf("got group %+v after rename", g)
+	}
+
+	if ok, err := client.Groups.Delete(ctx, ids[1]); err != nil || !ok {
+		t.Fatalf("delete: %v, %v", ok, err)
+	}
+	_, err = client.Groups.Get(ctx, ids[1])
+	wantStatus(t, err, http.StatusNotFound)
+	_, err = client.Groups.Delete(ctx, ids[1])
+	wantStatus(t, err, http.StatusNotFound)
+	all, err := client.Groups.ListAll(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 || all[0].UUID != ids[0] || all[1].UUID != ids[2] {
+		t.Errorf("got groups %+v after deleting the second", all)
+	}
+}
+
+func TestSubscriberRoutes(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	client := srv.Client()
+	ctx := context.Background()
+
+	g, err := client.Groups.Create(ctx, mepost.CreateNewGroupRequest{
+		Name: "customers",
+		To:   []mepost.To{{Email: "alice@example.com"}, {Email: "bob@example.com"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Adding an existing address, in any case, updates its custom fields.
+	_, err = client.Subscribers.Create(ctx, g.UUID, mepost.CreateSubscriberRequest{To: []mepost.To{
+		{Email: "ALICE@example.com", Customization: map[string]string{"plan": "pro", "city": "Oslo"}},
+		{Email: "carol@example.com"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.Subscribers.Create(ctx, g.UUID, mepost.CreateSubscriberRequest{To: []mepost.To{{Name: "No address"}}})
+	wantStatus(t, err, http.StatusBadRequest)
+	_, err = client.Subscribers.Create(ctx, "missing", mepost.CreateSubscriberRequest{To: []mepost.To{{Email: "dan@example.com"}}})
+	wantStatus(t, err, http.StatusNotFound)
+
+	alice, err := client.Subscribers.Get(ctx, g.UUID, "alice@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []mepost.CustomField{{Name: "city", Value: "Oslo"}, {Name: "plan", Value: "pro"}}
+	if fmt.Sprint(alice.CustomFields) != fmt.Sprint(want) || !alice.Confirmed {
+		t.Errorf("got subscriber %+v, want custom fields %v", alice, want)
+	}
+	_, err = client.Subscribers.Get(ctx, g.UUID, "dan@example.com")
+	wantStatus(t, err, http.StatusNotFound)
+
+	page, err := client.Subscribers.List(ctx, g.UUID, &mepost.ListSubscribersOptions{Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 3 || len(page.Data) != 2 || page.Data[1].EmailAddress != "bob@example.com" {
+		t.Errorf("got first page %+v, want 2 of 3 subscribers", page)
+	}
+
+	if _, err := client.Subscribers.Delete(ctx, g.UUID, mepost.DeleteSubscriberRequest{Emails: []string{"Carol@example.com", "dan@example.com"}}); err != nil {
+		t.Fatal(err)
+	}
+	all, err := client.Subscribers.ListAll(ctx, g.UUID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 || all[0].EmailAddress != "alice@example.com" || all[1].EmailAddress != "bob@example.com" {
+		t.Errorf("got subscribers %+v after deleting carol", all)
+	}
+}
+
+func TestSubscriberCounts(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	client := srv.Client()
+	ctx := context.Background()
+
+	created, err := client.Groups.Create(ctx, mepost.CreateNewGroupRequest{
+		Name: "counts",
+		To:   []mepost.To{{Email: "a@example.com"}, {Email: "b@example.com"}, {Email: "c@example.com"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The API offers no way to unsubscribe, so the server's state is changed directly.
+	srv.mu.Lock()
+	subscribers := srv.groups[0].subscribers
+	subscribers[0].Unsubscribed = true
+	subscribers[1].Bounced = true
+	srv.mu.Unlock()
+
+	tests := []struct {
+		name                                string
+		change                              func() error
+		total, active, unsubscribed, bounce int
+	}{
+		// Bounces are counted on every Get, the other totals when subscribers are added or removed.
+		{"created", func() error { return nil }, 3, 3, 0, 1},
+		{"added", func() error {
+			_, err := client.Subscribers.Create(ctx, created.UUID, mepost.CreateSubscriberRequest{To: []mepost.To{{Email: "d@example.com"}}})
+			return err
+		}, 4, 3, 1, 1},
+		{"removed", func() error {
+			_, err := client.Subscribers.Delete(ctx, created.UUID, mepost.DeleteSubscriberRequest{Emails: []string{"a@example.com", "b@example.com"}})
+			return err
+		}, 2, 2, 0, 0},
+	}
+	for _, tt := range tests {
+		if err := tt.change(); err != nil {
+			t.Fatal(err)
+		}
+		g, err := client.Groups.Get(ctx, created.UUID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if g.TotalSubscriber != tt.total || g.TotalActiveSubscriber != tt.active || g.TotalUnsubscribe != tt.unsubscribed || g.TotalBounced != tt.bounce {
+			t.Errorf("%s: got total %d, active %d, unsubscribed %d, bounced %d; want %d, %d, %d, %d", tt.name,
+				g.TotalSubscriber, g.TotalActiveSubscriber, g.TotalUnsubscribe, g.TotalBounced,
+				tt.total, tt.active, tt.unsubscribed, tt.bounce)
+		}
+	}
+}
+
+func TestDomainRoutes(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	client := srv.Client()
+	ctx := context.Background()
+
+	added, err := client.Domains.Create(ctx, mepost.AddDomainRequest{Domain: "example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added.DKIM.Name != "mepost._domainkey.example.com" || added.SPF.Type != "TXT" {
+		t.Errorf("got DNS records %+v", added)
+	}
+	_, err = client.Domains.Create(ctx, mepost.AddDomainRequest{Domain: "example.com"})
+	wantStatus(t, err, http.StatusConflict)
+	_, err = client.Domains.Create(ctx, mepost.AddDomainRequest{})
+	wantStatus(t, err, http.StatusBadRequest)
+
+	removed, err := client.Domains.Delete(ctx, mepost.RemoveDomainRequest{Domain: "example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed.Domain != "example.com" || removed.RemovedAt.IsZero() {
+		t.Errorf("got removal %+v", removed)
+	}
+	_, err = client.Domains.Delete(ctx, mepost.RemoveDomainRequest{Domain: "example.com"})
+	wantStatus(t, err, http.StatusNotFound)
+
+	_, err = mepost.Do[struct{}](ctx, client, http.MethodGet, "/no/such/route", nil, nil)
+	wantStatus(t, err, http.StatusNotFound)
+}
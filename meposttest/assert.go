@@ -0,0 +1,243 @@
+package meposttest
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// TestingT is the subset of testing.TB used by the assertion helpers.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// Matcher checks one property of a sent message.
+type Matcher struct {
+	desc  string
+	match func(m SentMessage) (ok bool, got string)
+}
+
+// String describes what the matcher expects.
+func (m Matcher) String() string {
+	return m.desc
+}
+
+// Match reports whether the message satisfies the matcher.
+func (m Matcher) Match(msg SentMessage) bool {
+	ok, _ := m.match(msg)
+	return ok
+}
+
+// Where returns a matcher that applies an arbitrary predicate.
+func Where(desc string, fn func(m SentMessage) bool) Matcher {
+	return Matcher{desc: desc, match: func(m SentMessage) (bool, string) {
+		return fn(m), "predicate returned false"
+	}}
+}
+
+// To matches messages sent to the given address, among possibly other recipients.
+func To(email string) Matcher {
+	return Matcher{desc: fmt.Sprintf("to %q", email), match: func(m SentMessage) (bool, string) {
+		for _, recipient := range m.Recipients() {
+			if strings.EqualFold(recipient, email) {
+				return true, ""
+			}
+		}
+		return false, fmt.Sprintf("%q", m.Recipients())
+	}}
+}
+
+// From matches messages sent from the given address.
+func From(email string) Matcher {
+	return Matcher{desc: fmt.Sprintf("from %q", email), match: func(m SentMessage) (bool, string) {
+		return strings.EqualFold(m.FromEmail, email), fmt.Sprintf("%q", m.FromEmail)
+	}}
+}
+
+// Subject matches messages with exactly the given subject.
+func Subject(subject string) Matcher {
+	return Matcher{desc: fmt.Sprintf("subject %q", subject), match: func(m SentMessage) (bool, string) {
+		return m.Subject == subject, fmt.Sprintf("%q", m.Subject)
+	}}
+}
+
+// SubjectContains matches messages whose subject contains substr.
+func SubjectContains(substr string) Matcher {
+	return Matcher{desc: fmt.Sprintf("subject containing %q", substr), match: func(m SentMessage) (bool, string) {
+		return strings.Contains(m.Subject, substr), fmt.Sprintf("%q", m.Subject)
+	}}
+}
+
+// HTMLContains matches messages whose HTML body contains substr.
+func HTMLContains(substr string) Matcher {
+	return Matcher{desc: fmt.Sprintf("HTML containing %q", substr), match: func(m SentMessage) (bool, string) {
+		return strings.Contains(m.Html, substr), excerpt(m.Html)
+	}}
+}
+
+// TextContains matches messages whose text body contains substr.
+func TextContains(substr string) Matcher {
+	return Matcher{desc: fmt.Sprintf("text containing %q", substr), match: func(m SentMessage) (bool, string) {
+		return strings.Contains(m.Text, substr), excerpt(m.Text)
+	}}
+}
+
+// BodyContains matches messages whose HTML or text body contains substr.
+func BodyContains(substr string) Matcher {
+	return Matcher{desc: fmt.Sprintf("body containing %q", substr), match: func(m SentMessage) (bool, string) {
+		ok := strings.Contains(m.Html, substr) || strings.Contains(m.Text, substr)
+		return ok, fmt.Sprintf("html %s, text %s", excerpt(m.Html), excerpt(m.Text))
+	}}
+}
+
+// BodyMatches matches messages whose HTML or text body matches the regular expression pattern.
+// It panics if pattern does not compile.
+func BodyMatches(pattern string) Matcher {
+	re := regexp.MustCompile(pattern)
+	return Matcher{desc: fmt.Sprintf("body matching /%s/", pattern), match: func(m SentMessage) (bool, string) {
+		ok := re.MatchString(m.Html) || re.MatchString(m.Text)
+		return ok, fmt.Sprintf("html %s, text %s", excerpt(m.Html), excerpt(m.Text))
+	}}
+}
+
+// Header matches messages with the given custom header value.
+func Header(name, value string) Matcher {
+	return Matcher{desc: fmt.Sprintf("header %s: %q", name, value), match: func(m SentMessage) (bool, string) {
+		for key, got := range m.Headers {
+			if strings.EqualFold(key, name) {
+				return got == value, fmt.Sprintf("%q", got)
+			}
+		}
+		return false, "no such header"
+	}}
+}
+
+// Attachment matches messages with an attachment of the given file name.
+func Attachment(fileName string) Matcher {
+	return Matcher{desc: fmt.Sprintf("attachment %q", fileName), match: func(m SentMessage) (bool, string) {
+		names := make([]string, len(m.Attachments))
+		for i, attachment := range m.Attachments {
+			if attachment.FileName == fileName {
+				return true, ""
+			}
+			names[i] = attachment.FileName
+		}
+		return false, fmt.Sprintf("%q", names)
+	}}
+}
+
+// Template matches messages sent with the given template ID.
+func Template(templateID string) Matcher {
+	return Matcher{desc: fmt.Sprintf("template %q", templateID), match: func(m SentMessage) (bool, string) {
+		return m.TemplateID == templateID, fmt.Sprintf("%q", m.TemplateID)
+	}}
+}
+
+// Transactional matches messages sent through a transactional endpoint.
+func Transactional() Matcher {
+	return Matcher{desc: "transactional", match: func(m SentMessage) (bool, string) {
+		return m.Transactional, m.Endpoint
+	}}
+}
+
+// Marketing matches messages sent through a marketing endpoint.
+func Marketing() Matcher {
+	return Matcher{desc: "marketing", match: func(m SentMessage) (bool, string) {
+		return !m.Transactional, m.Endpoint
+	}}
+}
+
+// FindSent returns the messages that satisfy all matchers.
+func FindSent(messages []SentMessage, matchers ...Matcher) []SentMessage {
+	var found []SentMessage
+	for _, m := range messages {
+		if matchAll(m, matchers) {
+			found = append(found, m)
+		}
+	}
+	return found
+}
+
+// AssertSent fails the test unless at least one sent message satisfies all matchers,
+// and returns the first such message.
+func (s *Server) AssertSent(t TestingT, matchers ...Matcher) SentMessage {
+	t.Helper()
+	sent := s.Sent()
+	found := FindSent(sent, matchers...)
+	if len(found) == 0 {
+		t.Errorf("meposttest: no sent message %s\n%s", describe(matchers), report(sent, matchers))
+		return SentMessage{}
+	}
+	return found[0]
+}
+
+// AssertSentCount fails the test unless exactly n sent messages satisfy all matchers.
+func (s *Server) AssertSentCount(t TestingT, n int, matchers ...Matcher) []SentMessage {
+	t.Helper()
+	sent := s.Sent()
+	found := FindSent(sent, matchers...)
+	if len(found) != n {
+		t.Errorf("meposttest: expected %d sent messages %s, found %d\n%s", n, describe(matchers), len(found), report(sent, matchers))
+	}
+	return found
+}
+
+// AssertNotSent fails the test if any sent message satisfies all matchers.
+func (s *Server) AssertNotSent(t TestingT, matchers ...Matcher) {
+	t.Helper()
+	sent := s.Sent()
+	if found := FindSent(sent, matchers...); len(found) > 0 {
+		t.Errorf("meposttest: unexpected sent message %s\n%s", describe(matchers), report(found, nil))
+	}
+}
+
+func matchAll(m SentMessage, matchers []Matcher) bool {
+	for _, matcher := range matchers {
+		if !matcher.Match(m) {
+			return false
+		}
+	}
+	return true
+}
+
+func describe(matchers []Matcher) string {
+	if len(matchers) == 0 {
+		return "at all"
+	}
+	descs := make([]string, len(matchers))
+	for i, matcher := range matchers {
+		descs[i] = matcher.desc
+	}
+	return "matching " + strings.Join(descs, ", ")
+}
+
+// report lists the messages and, for each, the matchers it failed with the actual values.
+func report(messages []SentMessage, matchers []Matcher) string {
+	if len(messages) == 0 {
+		return "no messages were sent"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "sent messages (%d):", len(messages))
+	for i, m := range messages {
+		fmt.Fprintf(&b, "\n  #%d %s %q from %q to %q", i+1, m.Endpoint, m.Subject, m.FromEmail, m.Recipients())
+		if m.TemplateID != "" {
+			fmt.Fprintf(&b, " template %q", m.TemplateID)
+		}
+		for _, matcher := range matchers {
+			if ok, got := matcher.match(m); !ok {
+				fmt.Fprintf(&b, "\n      want %s, got %s", matcher.desc, got)
+			}
+		}
+	}
+	return b.String()
+}
+
+// excerpt quotes s, shortening it so that failure reports stay readable.
+func excerpt(s string) string {
+	const max = 120
+	if len(s) > max {
+		return fmt.Sprintf("%q...", s[:max])
+	}
+	return fmt.Sprintf("%q", s)
+}
@@ -0,0 +1,168 @@
+package meposttest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	mepost "github.com/mepost-io/golang-sdk"
+)
+
+// fakeT records the failures reported by the assertion helpers.
+type fakeT struct {
+	helpers int
+	errors  []string
+}
+
+func (t *fakeT) Helper() {
+	t.helpers++
+}
+
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+var receipt = SentMessage{
+	Endpoint:      "transactional",
+	Transactional: true,
+	FromEmail:     "noreply@example.com",
+	Subject:       "Your receipt #42",
+	Html:          "<p>Total: <b>$10</b></p>",
+	Text:          "Total: $10",
+	To:            []mepost.To{{Email: "alice@example.com"}, {Email: "bob@example.com"}},
+	Headers:       map[string]string{"X-Order": "42"},
+	Attachments:   []mepost.AttachmentDto{{FileName: "receipt.pdf"}},
+}
+
+func TestMatchers(t *testing.T) {
+	newsletter := SentMessage{Endpoint: "marketing-by-template", TemplateID: "tpl-1", Html: strings.Repeat("x", 200)}
+	tests := []struct {
+		matcher Matcher
+		desc    string
+		msg     SentMessage
+		want    bool
+		// got is the actual value reported when the matcher fails.
+		got string
+	}{
+		{To("ALICE@example.com"), `to "ALICE@example.com"`, receipt, true, ""},
+		{To("carol@example.com"), `to "carol@example.com"`, receipt, false, `["alice@example.com" "bob@example.com"]`},
+		{From("NoReply@example.com"), `from "NoReply@example.com"`, receipt, true, ""},
+		{From("sales@example.com"), `from "sales@example.com"`, receipt, false, `"noreply@example.com"`},
+		{Subject("Your receipt #42"), `subject "Your receipt #42"`, receipt, true, ""},
+		{Subject("your receipt #42"), `subject "your receipt #42"`, receipt, false, `"Your receipt #42"`},
+		{SubjectContains("receipt"), `subject containing "receipt"`, receipt, true, ""},
+		{HTMLContains("<b>$10</b>"), `HTML containing "<b>$10</b>"`, receipt, true, ""},
+		{HTMLContains("y"), `HTML containing "y"`, newsletter, false, `"` + strings.Repeat("x", 120) + `"...`},
+		{TextContains("<b>"), `text containing "<b>"`, receipt, false, `"Total: $10"`},
+		{BodyContains("$10"), `body containing "$10"`, receipt, true, ""},
+		{BodyContains("refund"), `body containing "refund"`, receipt, false, `html "<p>Total: <b>$10</b></p>", text "Total: $10"`},
+		{BodyMatches(`\$\d+`), `body matching /\$\d+/`, receipt, true, ""},
+		{BodyMatches(`^Total`), `body matching /^Total/`, SentMessage{}, false, `html "", text ""`},
+		{Header("x-order", "42"), `header x-order: "42"`, receipt, true, ""},
+		{Header("X-Order", "43"), `header X-Order: "43"`, receipt, false, `"42"`},
+		{Header("X-Missing", ""), `header X-Missing: ""`, receipt, false, "no such header"},
+		{Attachment("receipt.pdf"), `attachment "receipt.pdf"`, receipt, true, ""},
+		{Attachment("invoice.pdf"), `attachment "invoice.pdf"`, receipt, false, `["receipt.pdf"]`},
+		{Template("tpl-1"), `template "tpl-1"`, newsletter, true, ""},
+		{Template("tpl-2"), `template "tpl-2"`, receipt, false, `""`},
+		{Transactional(), "transactional", receipt, true, ""},
+		{Transactional(), "transactional", newsletter, false, "marketing-by-template"},
+		{Marketing(), "marketing", newsletter, true, ""},
+		{Where("two recipients", func(m SentMessage) bool { return len(m.To) == 2 }), "two recipients", receipt, true, ""},
+		{Where("no recipients", func(m SentMessage) bool { return len(m.To) == 0 }), "no recipients", receipt, false, "predicate returned false"},
+	}
+	for _, tt := range tests {
+		if desc := tt.matcher.String(); desc != tt.desc {
+			t.Errorf("got description %s, want %s", desc, tt.desc)
+		}
+		if ok := tt.matcher.Match(tt.msg); ok != tt.want {
+			t.Errorf("%s: got match %v, want %v", tt.desc, ok, tt.want)
+		}
+		if ok, got := tt.matcher.match(tt.msg); !ok && got != tt.got {
+			t.Errorf("%s: reported %s, want %s", tt.desc, got, tt.got)
+		}
+	}
+}
+
+func TestFindSent(t *testing.T) {
+	other := receipt
+	other.To = []mepost.To{{Email: "carol@example.com"}}
+	messages := []SentMessage{receipt, other}
+	if found := FindSent(messages); len(found) != 2 {
+		t.Errorf("got %d messages without matchers, want all", len(found))
+	}
+	if found := FindSent(messages, From("noreply@example.com"), To("carol@example.com")); len(found) != 1 || found[0].To[0].Email != "carol@example.com" {
+		t.Errorf("got %+v, want the message to carol", found)
+	}
+	if found := FindSent(messages, To("carol@example.com"), To("alice@example.com")); len(found) != 0 {
+		t.Errorf("got %d messages matching exclusive matchers, want none", len(found))
+	}
+}
+
+func TestAssertions(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	client := srv.Client()
+	for _, to := range []string{"alice@example.com", "bob@example.com"} {
+		_, err := client.Messages.SendTransactional(context.Background(), mepost.SendTransactionalRequest{
+			FromEmail: "noreply@example.com",
+			Subject:   "Welcome",
+			Text:      "Hello",
+			To:        []mepost.To{{Email: to}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		assert func(TestingT)
+		// failure holds the lines of the reported failure, or is nil if the assertion passes.
+		failure []string
+	}{
+		{"sent", func(t TestingT) { srv.AssertSent(t, To("bob@example.com"), Subject("Welcome")) }, nil},
+		{"not sent", func(t TestingT) { srv.AssertSent(t, To("carol@example.com"), Subject("Welcome")) }, []string{
+			`meposttest: no sent message matching to "carol@example.com", subject "Welcome"`,
+			`sent messages (2):`,
+			`  #1 transactional "Welcome" from "noreply@example.com" to ["alice@example.com"]`,
+			`      want to "carol@example.com", got ["alice@example.com"]`,
+			`  #2 transactional "Welcome" from "noreply@example.com" to ["bob@example.com"]`,
+			`      want to "carol@example.com", got ["bob@example.com"]`,
+		}},
+		{"count", func(t TestingT) { srv.AssertSentCount(t, 2, Transactional()) }, nil},
+		{"wrong count", func(t TestingT) { srv.AssertSentCount(t, 1) }, []string{
+			`meposttest: expected 1 sent messages at all, found 2`,
+			`sent messages (2):`,
+			`  #1 transactional "Welcome" from "noreply@example.com" to ["alice@example.com"]`,
+			`  #2 transactional "Welcome" from "noreply@example.com" to ["bob@example.com"]`,
+		}},
+		{"absent", func(t TestingT) { srv.AssertNotSent(t, Marketing()) }, nil},
+		{"unexpected", func(t TestingT) { srv.AssertNotSent(t, To("alice@example.com")) }, []string{
+			`meposttest: unexpected sent message matching to "alice@example.com"`,
+			`sent messages (1):`,
+			`  #1 transactional "Welcome" from "noreply@example.com" to ["alice@example.com"]`,
+		}},
+	}
+	for _, tt := range tests {
+		ft := &fakeT{}
+		tt.assert(ft)
+		if ft.helpers == 0 {
+			t.Errorf("%s: Helper was not called", tt.name)
+		}
+		var got []string
+		if len(ft.errors) > 0 {
+			got = strings.Split(strings.Join(ft.errors, "\n"), "\n")
+		}
+		if strings.Join(got, "\n") != strings.Join(tt.failure, "\n") {
+			t.Errorf("%s: got failure\n%s\nwant\n%s", tt.name, strings.Join(got, "\n"), strings.Join(tt.failure, "\n"))
+		}
+	}
+
+	srv.Reset()
+	ft := &fakeT{}
+	if msg := srv.AssertSent(ft); msg.Endpoint != "" || len(ft.errors) != 1 || !strings.HasSuffix(ft.errors[0], "\nno messages were sent") {
+		t.Errorf("got message %+v and failures %q with nothing sent", msg, ft.errors)
+	}
+}
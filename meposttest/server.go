@@ -0,0 +1,368 @@
+// Package meposttest provides an in-memory fake of the Mepost API for tests.
+//
+// A Server keeps groups, subscribers, domains, IP groups and warm-ups in memory,
+// records every message sent through it, and can inject faults such as latency,
+// error statuses and malformed responses:
+//
+//	srv := meposttest.NewServer()
+//	defer srv.Close()
+//
+//	client := srv.Client()
+//	// exercise code that uses client ...
+//
+//	if len(srv.Sent()) != 1 {
+//		t.Fatal("expected one message to be sent")
+//	}
+package meposttest
+
+import (
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	mepost "github.com/mepost-io/golang-sdk"
+)
+
+// Server is a stateful fake of the Mepost API served over HTTP.
+type Server struct {
+	// URL is the base URL of the server, suitable for Client.BaseURL.
+	URL string
+
+	srv *httptest.Server
+
+	mu       sync.Mutex
+	nextID   int
+	groups   []*group
+	domains  map[string]*mepost.AddDomainResponse
+	ipGroups []*mepost.IPGroup
+	ips      []*mepost.IpAddress
+	warmups  map[string]*mepost.StartWarmUpResponse
+	sent     []SentMessage
+	requests []Request
+	faults   []*Fault
+	apiKey   string
+
+	rejectCompression bool
+}
+
+// Request is a request received by the server.
+type Request struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Body   []byte
+}
+
+// Fault describes an error condition the server injects into matching requests.
+type Fault struct {
+	// Method and Path restrict the fault to matching requests. Path matches as a
+	// prefix of the request path, e.g. "/messages/". Empty values match any request.
+	Method string
+	Path   string
+	// Latency delays the response.
+	Latency time.Duration
+	// Status responds with the given HTTP status and an error body instead of
+	// handling the request.
+	Status int
+	// RetryAfter sets the Retry-After header on Status responses.
+	RetryAfter time.Duration
+	// Malformed responds with a body that is not valid JSON.
+	Malformed bool
+	// Times limits the fault to the given number of requests. Zero means unlimited.
+	Times int
+}
+
+// NewServer starts a new fake server. Callers should call Close when finished.
+func NewServer() *Server {
+	s := &Server{
+		domains: make(map[string]*mepost.AddDomainResponse),
+		warmups: make(map[string]*mepost.StartWarmUpResponse),
+	}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.srv.URL
+	return s
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// Client returns a client configured to talk to the server.
+func (s *Server) Client() *mepost.Client {
+	client := mepost.NewClient("meposttest-api-key")
+	client.BaseURL = s.URL
+	return client
+}
+
+// RequireAPIKey makes the server reject requests whose Authorization header is not key.
+// By default any non-empty key is accepted.
+func (s *Server) RequireAPIKey(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.apiKey = key
+}
+
+// RejectCompression makes the server reject compressed request bodies with
+// 415 Unsupported Media Type. By default gzipped bodies are accepted.
+func (s *Server) RejectCompression() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rejectCompression = true
+}
+
+// InjectFault adds a fault. Faults stack: a request is delayed by the latency of
+// every matching fault and answered by the first one that sets Status or Malformed.
+func (s *Server) InjectFault(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &f)
+}
+
+// FailNext makes the next n requests fail with the given HTTP status.
+func (s *Server) FailNext(n, status int) {
+	s.InjectFault(Fault{Status: status, Times: n})
+}
+
+// SetLatency delays every response by d.
+func (s *Server) SetLatency(d time.Duration) {
+	s.InjectFault(Fault{Latency: d})
+}
+
+// ClearFaults removes all injected faults.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// Requests returns the requests received by the server, in order.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// Sent returns the messages sent through the server, in order.
+func (s *Server) Sent() []SentMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]SentMessage(nil), s.sent...)
+}
+
+// Reset clears all state, recorded requests, sent messages and faults.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.groups = nil
+	s.domains = make(map[string]*mepost.AddDomainResponse)
+	s.ipGroups = nil
+	s.ips = nil
+	s.warmups = make(map[string]*mepost.StartWarmUpResponse)
+	s.sent = nil
+	s.requests = nil
+	s.faults = nil
+}
+
+// AddIP provisions an outbound IP address. IPs cannot be created through the API,
+// so tests that use the outbound endpoints must add them first.
+func (s *Server) AddIP(ip string) *mepost.IpAddress {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := mepost.Time{Time: time.Now().UTC()}
+	address := &mepost.IpAddress{
+		CompanyId: 1,
+		CreatedAt: now,
+		Ip:        ip,
+		Status:    mepost.IPStatusActive,
+		UpdatedAt: now,
+		UUID:      newUUID(),
+	}
+	s.ips = append(s.ips, address)
+	return address
+}
+
+// httpError is returned by handlers to produce an error response.
+type httpError struct {
+	status  int
+	message string
+}
+
+func (e *httpError) Error() string {
+	return e.message
+}
+
+func errorf(status int, format string, args ...interface{}) error {
+	return &httpError{status: status, message: fmt.Sprintf(format, args...)}
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	rejectCompression := s.rejectCompression
+	s.mu.Unlock()
+	if rejectCompression && r.Header.Get("Content-Encoding") != "" {
+		writeError(w, errorf(http.StatusUnsupportedMediaType, "unsupported content encoding %q", r.Header.Get("Content-Encoding")))
+		return
+	}
+	body, err := readBody(r)
+	if err != nil {
+		writeError(w, errorf(http.StatusBadRequest, "error reading body: %v", err))
+		return
+	}
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.Query(),
+		Header: r.Header.Clone(),
+		Body:   body,
+	})
+	fault := s.matchFault(r)
+	apiKey := s.apiKey
+	s.mu.Unlock()
+
+	if fault != nil {
+		if fault.Latency > 0 {
+			select {
+			case <-time.After(fault.Latency):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		if fault.Malformed {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, `{"malformed":`)
+			return
+		}
+		if fault.Status != 0 {
+			if fault.RetryAfter > 0 {
+				w.Header().Set("Retry-After", fmt.Sprint(int(fault.RetryAfter.Seconds())))
+			}
+			writeError(w, errorf(fault.Status, "injected fault"))
+			return
+		}
+	}
+
+	key := r.Header.Get("Authorization")
+	if key == "" || (apiKey != "" && key != apiKey) {
+		writeError(w, errorf(http.StatusUnauthorized, "invalid API key"))
+		return
+	}
+
+	result, err := s.route(r.Method, splitPath(r.URL), r.URL.Query(), body)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// matchFault combines the faults matching r: their latencies add up, and the
+// first of them that sets Status or Malformed decides the response. One use is
+// consumed from each fault that applied. It must be called with s.mu held.
+func (s *Server) matchFault(r *http.Request) *Fault {
+	var combined *Fault
+	decided := false
+	kept := s.faults[:0]
+	for _, f := range s.faults {
+		matches := (f.Method == "" || f.Method == r.Method) &&
+			(f.Path == "" || strings.HasPrefix(r.URL.Path, f.Path))
+		responds := f.Status != 0 || f.Malformed
+		if !matches || (responds && decided) {
+			kept = append(kept, f)
+			continue
+		}
+		if combined == nil {
+			combined = &Fault{}
+		}
+		combined.Latency += f.Latency
+		if responds {
+			combined.Status, combined.RetryAfter, combined.Malformed = f.Status, f.RetryAfter, f.Malformed
+			decided = true
+		}
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				continue
+			}
+		}
+		kept = append(kept, f)
+	}
+	s.faults = kept
+	return combined
+}
+
+func readBody(r *http.Request) ([]byte, error) {
+	defer r.Body.Close()
+	if !strings.EqualFold(r.Header.Get("Content-Encoding"), "gzip") {
+		return io.ReadAll(r.Body)
+	}
+	zr, err := gzip.NewReader(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
+
+// splitPath splits the escaped request path into unescaped segments, so that
+// escaped slashes inside a segment are preserved.
+func splitPath(u *url.URL) []string {
+	escaped := strings.Trim(u.EscapedPath(), "/")
+	if escaped == "" {
+		return nil
+	}
+	parts := strings.Split(escaped, "/")
+	for i, part := range parts {
+		if unescaped, err := url.PathUnescape(part); err == nil {
+			parts[i] = unescaped
+		}
+	}
+	return parts
+}
+
+func decode(body []byte, v interface{}) error {
+	if err := json.Unmarshal(body, v); err != nil {
+		return errorf(http.StatusBadRequest, "invalid request body: %v", err)
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if he, ok := err.(*httpError); ok {
+		status = he.status
+	}
+	writeJSON(w, status, mepost.ApiResponse[interface{}]{
+		Success: false,
+		Errors: []mepost.ErrorResponse{{
+			Code:    status,
+			Message: err.Error(),
+			Type:    strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_"),
+		}},
+	})
+}
+
+func newUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
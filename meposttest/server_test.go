@@ -0,0 +1,145 @@
+package meposttest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	mepost "github.com/mepost-io/golang-sdk"
+)
+
+func TestFaultsStack(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	client := srv.Client()
+	client.Retry = mepost.RetryPolicy{}
+
+	srv.SetLatency(20 * time.Millisecond)
+	srv.FailNext(1, http.StatusInternalServerError)
+	srv.FailNext(1, http.StatusTooManyRequests)
+
+	ctx := context.Background()
+	for _, want := range []int{http.StatusInternalServerError, http.StatusTooManyRequests, 0} {
+		start := time.Now()
+		_, err := client.Outbound.IPGroups.List(ctx)
+		if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+			t.Errorf("request took %v, want at least the injected latency", elapsed)
+		}
+		var apiErr *mepost.APIError
+		switch {
+		case want == 0 && err != nil:
+			t.Errorf("got error %v after the failures were used up", err)
+		case want != 0 && (!errors.As(err, &apiErr) || apiErr.StatusCode != want):
+			t.Errorf("got error %v, want status %d", err, want)
+		}
+	}
+}
+
+var hostileNames = []string{
+	"alice+tag@example.com",
+	"a/b",
+	"100%",
+	"what?",
+	"#tag",
+	".",
+	"..",
+	"with space",
+	"grüppe ✓",
+	"../../admin",
+}
+
+func TestSplitPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"/", nil},
+		{"/groups/", []string{"groups"}},
+		{"/groups/g%2F1/subscribers/a%2Bb@example.com", []string{"groups", "g/1", "subscribers", "a+b@example.com"}},
+		{"/outbound/ip-group/info/%2E%2E", []string{"outbound", "ip-group", "info", ".."}},
+		{"/outbound/ip-group/info/100%25%3F%23", []string{"outbound", "ip-group", "info", "100%?#"}},
+	}
+	for _, tt := range tests {
+		u, err := url.Parse("http://example.com" + tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := splitPath(u); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+// TestPathRoundTrip checks that names escaped by the client arrive at the
+// server as a single, unchanged path segment.
+func TestPathRoundTrip(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	client := srv.Client()
+	ctx := context.Background()
+
+	for _, name := range hostileNames {
+		if _, err := client.Outbound.IPGroups.Create(ctx, mepost.CreateIpGroupRequest{GroupName: name}); err != nil {
+			t.Fatalf("creating %q: %v", name, err)
+		}
+		group, err := client.Outbound.IPGroups.Get(ctx, name)
+		if err != nil {
+			t.Errorf("getting %q: %v", name, err)
+			continue
+		}
+		if group.Name != name {
+			t.Errorf("getting %q returned group %q", name, group.Name)
+		}
+		requests := srv.Requests()
+		if got := requests[len(requests)-1].Path; got != "/outbound/ip-group/info/"+name {
+			t.Errorf("getting %q requested path %q", name, got)
+		}
+	}
+}
+
+func TestRejectCompression(t *testing.T) {
+	request := mepost.SendMarketingRequest{FromEmail: "news@example.com", Subject: "News"}
+	for i := 0; i < 1000; i++ {
+		request.To = append(request.To, fmt.Sprintf("subscriber%d@example.com", i))
+	}
+	for _, reject := range []bool{false, true} {
+		t.Run(fmt.Sprintf("reject=%v", reject), func(t *testing.T) {
+			srv := NewServer()
+			defer srv.Close()
+			if reject {
+				srv.RejectCompression()
+			}
+			client := srv.Client()
+			client.Compression.Threshold = 1 << 10
+			ctx := context.Background()
+
+			for i := 0; i < 2; i++ {
+				if _, err := client.Messages.SendMarketing(ctx, request); err != nil {
+					t.Fatalf("send %d: %v", i, err)
+				}
+			}
+			// Rejected requests are not recorded.
+			requests := srv.Requests()
+			if len(requests) != 2 {
+				t.Fatalf("got %d requests, want 2", len(requests))
+			}
+			want := "gzip"
+			if reject {
+				want = ""
+			}
+			for i, r := range requests {
+				if got := r.Header.Get("Content-Encoding"); got != want {
+					t.Errorf("request %d: got Content-Encoding %q, want %q", i, got, want)
+				}
+			}
+			if sent := srv.Sent(); len(sent) != 2 || len(sent[1].To) != len(request.To) {
+				t.Errorf("got %d sent messages, want 2 to %d recipients", len(sent), len(request.To))
+			}
+		})
+	}
+}
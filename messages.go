@@ -0,0 +1,90 @@
+package mepost
+
+import (
+	"context"
+	"time"
+)
+
+// MessagesService sends messages.
+type MessagesService struct {
+	client *Client
+}
+
+// SendTransactional sends a transactional email.
+func (s *MessagesService) SendTransactional(ctx context.Context, request SendTransactionalRequest) (*Schedule, error) {
+	s.client.Defaults.apply(&request.FromEmail, &request.FromName, &request.IpGroup)
+	if err := checkSchedule(&request.ScheduledAt, time.Now()); err != nil {
+		return nil, err
+	}
+	url, err := s.client.buildURL(nil, "messages", "transactional")
+	if err != nil {
+		return nil, err
+	}
+	response := &Schedule{}
+	err = s.client.makeRequest(ctx, OpSendTransactional, "POST", url, request, response)
+	return response, err
+}
+
+// SendTransactionalTemplate sends a transactional email using a template.
+func (s *MessagesService) SendTransactionalTemplate(ctx context.Context, request SendMessageByTemplateRequest) (*Schedule, error) {
+	s.client.Defaults.apply(&request.Message.FromEmail, &request.Message.FromName, &request.Message.IpGroup)
+	if err := checkSchedule(&request.Message.ScheduledAt, time.Now()); err != nil {
+		return nil, err
+	}
+	url, err := s.client.buildURL(nil, "messages", "transactional-by-template")
+	if err != nil {
+		return nil, err
+	}
+	response := &Schedule{}
+	err = s.client.makeRequest(ctx, OpSendTransactionalTemplate, "POST", url, request, response)
+	return response, err
+}
+
+// SendMarketing sends a marketing email.
+func (s *MessagesService) SendMarketing(ctx context.Context, request SendMarketingRequest) (*Schedule, error) {
+	s.client.Defaults.apply(&request.FromEmail, &request.FromName, &request.IpGroup)
+	if err := checkSchedule(&request.ScheduledAt, time.Now()); err != nil {
+		return nil, err
+	}
+	url, err := s.client.buildURL(nil, "messages", "marketing")
+	if err != nil {
+		return nil, err
+	}
+	response := &Schedule{}
+	err = s.client.makeRequest(ctx, OpSendMarketing, "POST", url, request, response)
+	return response, err
+}
+
+// SendMarketingTemplate sends a marketing email using a template.
+func (s *MessagesService) SendMarketingTemplate(ctx context.Context, request SendMessageByTemplateRequest) (*Schedule, error) {
+	s.client.Defaults.apply(&request.Message.FromEmail, &request.Message.FromName, &request.Message.IpGroup)
+	if err := checkSchedule(&request.Message.ScheduledAt, time.Now()); err != nil {
+		return nil, err
+	}
+	url, err := s.client.buildURL(nil, "messages", "marketing-by-template")
+	if err != nil {
+		return nil, err
+	}
+	response := &Schedule{}
+	err = s.client.makeRequest(ctx, OpSendMarketingTemplate, "POST", url, request, response)
+	return response, err
+}
+
+// MessageDefaults holds values used for message fields that are left empty.
+type MessageDefaults struct {
+	FromEmail string
+	FromName  string
+	IpGroup   string
+}
+
+func (d MessageDefaults) apply(fromEmail, fromName, ipGroup *string) {
+	if *fromEmail == "" {
+		*fromEmail = d.FromEmail
+	}
+	if *fromName == "" {
+		*fromName = d.FromName
+	}
+	if *ipGroup == "" {
+		*ipGroup = d.IpGroup
+	}
+}
@@ -0,0 +1,70 @@
+package mepost
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ResponseMeta describes the HTTP response to an API call.
+type ResponseMeta struct {
+	StatusCode int
+	// RequestID is the ID the API assigned to the request, if it reported one.
+	RequestID string
+	// RateLimit holds the rate-limit headers.
+	RateLimit RateLimitInfo
+	// RetryAfter is the delay requested by a Retry-After header, or zero.
+	RetryAfter time.Duration
+	// Quota holds the headers whose name contains "Quota", e.g. X-Quota-Remaining.
+	Quota map[string]string
+	// Header holds all response headers.
+	Header http.Header
+	// Attempts is the number of HTTP requests made for the call, including retries.
+	Attempts int
+}
+
+type captureKey struct{}
+
+// CaptureResponse returns a context that makes the API call it is passed to
+// store the metadata of its last HTTP response in meta. meta is also filled
+// when the call fails, as long as a response was received. It is left
+// unchanged when the result is served by Client.Cache.
+//
+//	var meta mepost.ResponseMeta
+//	schedule, err := client.Messages.SendTransactional(mepost.CaptureResponse(ctx, &meta), request)
+//	if meta.RateLimit.HasRemaining && meta.RateLimit.Remaining < 10 {
+//		// slow down
+//	}
+func CaptureResponse(ctx context.Context, meta *ResponseMeta) context.Context {
+	return context.WithValue(ctx, captureKey{}, meta)
+}
+
+// captureResponse fills the ResponseMeta registered with CaptureResponse, if any.
+func captureResponse(ctx context.Context, op *Operation, resp *http.Response) {
+	meta, ok := ctx.Value(captureKey{}).(*ResponseMeta)
+	if !ok || meta == nil {
+		return
+	}
+	*meta = newResponseMeta(resp, op.Attempts)
+}
+
+func newResponseMeta(resp *http.Response, attempts int) ResponseMeta {
+	meta := ResponseMeta{
+		StatusCode: resp.StatusCode,
+		RequestID:  requestID(resp.Header),
+		RateLimit:  parseRateLimitHeaders(resp.Header, time.Now()),
+		Header:     resp.Header,
+		Attempts:   attempts,
+	}
+	meta.RetryAfter, _ = retryAfter(resp.Header)
+	for name, values := range resp.Header {
+		if strings.Contains(strings.ToLower(name), "quota") && len(values) > 0 {
+			if meta.Quota == nil {
+				meta.Quota = map[string]string{}
+			}
+			meta.Quota[name] = values[0]
+		}
+	}
+	return meta
+}
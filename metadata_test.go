@@ -0,0 +1,104 @@
+package mepost
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// cannedResponse is a response of metadataServer.
+type cannedResponse struct {
+	status int
+	header map[string]string
+}
+
+// metadataServer answers IP lists with the given responses in turn, each with
+// a request ID counting from req-1.
+func metadataServer(responses []cannedResponse) *httptest.Server {
+	var requests atomic.Int32
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(requests.Add(1))
+		resp := responses[(n-1)%len(responses)]
+		for name, value := range resp.header {
+			w.Header().Set(name, value)
+		}
+		w.Header().Set("X-Request-Id", fmt.Sprint("req-", n))
+		w.WriteHeader(resp.status)
+		if resp.status == http.StatusOK {
+			w.Write([]byte(`[]`))
+		}
+	}))
+}
+
+func TestCaptureResponse(t *testing.T) {
+	unavailable := cannedResponse{http.StatusServiceUnavailable, map[string]string{
+		"Retry-After":           "0",
+		"X-RateLimit-Remaining": "0",
+		"X-Quota-Daily":         "0",
+	}}
+	ok := cannedResponse{http.StatusOK, map[string]string{
+		"X-RateLimit-Limit":     "100",
+		"X-RateLimit-Remaining": "99",
+		"X-Quota-Daily":         "500",
+		"X-Monthly-Quota":       "9000",
+	}}
+	tests := []struct {
+		name      string
+		responses []cannedResponse
+		want      string
+		// quota is the value of X-Quota-Daily.
+		quota string
+	}{
+		{"success", []cannedResponse{ok},
+			"status=200 id=req-1 attempts=1 limit=100 remaining=99/true retry-after=0s quotas=2", "500"},
+		{"after a retry", []cannedResponse{unavailable, ok},
+			"status=200 id=req-2 attempts=2 limit=100 remaining=99/true retry-after=0s quotas=2", "500"},
+		{"failed", []cannedResponse{unavailable},
+			"status=503 id=req-2 attempts=2 limit=0 remaining=0/true retry-after=0s quotas=1", "0"},
+		{"retry after", []cannedResponse{{http.StatusTooManyRequests, map[string]string{"Retry-After": "120"}}},
+			"status=429 id=req-1 attempts=1 limit=0 remaining=0/false retry-after=2m0s quotas=0", ""},
+	}
+	for _, tt := range tests {
+		srv := metadataServer(tt.responses)
+		c := NewClient("key")
+		c.BaseURL = srv.URL
+		c.Retry = RetryPolicy{MaxRetries: 1, MinBackoff: time.Millisecond, MaxBackoff: time.Second}
+		var meta ResponseMeta
+		_, err := c.Outbound.IPs.List(CaptureResponse(context.Background(), &meta))
+		srv.Close()
+		if (err == nil) != (meta.StatusCode == http.StatusOK) {
+			t.Errorf("%s: got error %v", tt.name, err)
+		}
+
+		got := fmt.Sprintf("status=%d id=%s attempts=%d limit=%d remaining=%d/%v retry-after=%v quotas=%d",
+			meta.StatusCode, meta.RequestID, meta.Attempts, meta.RateLimit.Limit, meta.RateLimit.Remaining,
+			meta.RateLimit.HasRemaining, meta.RetryAfter, len(meta.Quota))
+		if got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+		if meta.Quota["X-Quota-Daily"] != tt.quota {
+			t.Errorf("%s: got quotas %v, want X-Quota-Daily %q", tt.name, meta.Quota, tt.quota)
+		}
+		if id := meta.Header.Get("X-Request-Id"); id != meta.RequestID {
+			t.Errorf("%s: got X-Request-Id header %q, want %q", tt.name, id, meta.RequestID)
+		}
+	}
+}
+
+func TestCaptureResponseWithoutResponse(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	c := NewClient("key")
+	c.BaseURL = srv.URL
+	meta := ResponseMeta{StatusCode: -1}
+	if _, err := c.Outbound.IPs.List(CaptureResponse(context.Background(), &meta)); err == nil {
+		t.Fatal("got no error from a closed server")
+	}
+	if meta.StatusCode != -1 || meta.Header != nil {
+		t.Errorf("got response meta %+v without a response, want it unchanged", meta)
+	}
+}
@@ -0,0 +1,105 @@
+package mepost
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Operation names, as reported in Operation.Name.
+const (
+	OpCreateDomain = "CreateDomain"
+	OpDeleteDomain = "DeleteDomain"
+
+	OpListGroups  = "ListGroups"
+	OpGetGroup    = "GetGroup"
+	OpCreateGroup = "CreateGroup"
+	OpUpdateGroup = "UpdateGroup"
+	OpDeleteGroup = "DeleteGroup"
+
+	OpListSubscribers  = "ListSubscribers"
+	OpGetSubscriber    = "GetSubscriber"
+	OpCreateSubscriber = "CreateSubscriber"
+	OpDeleteSubscriber = "DeleteSubscriber"
+
+	OpSendTransactional         = "SendTransactional"
+	OpSendTransactionalTemplate = "SendTransactionalTemplate"
+	OpSendMarketing             = "SendMarketing"
+	OpSendMarketingTemplate     = "SendMarketingTemplate"
+
+	OpListIPs      = "ListIPs"
+	OpGetIP        = "GetIP"
+	OpSetIPGroup   = "SetIPGroup"
+	OpStartWarmup  = "StartWarmup"
+	OpCancelWarmup = "CancelWarmup"
+
+	OpListIPGroups  = "ListIPGroups"
+	OpGetIPGroup    = "GetIPGroup"
+	OpCreateIPGroup = "CreateIPGroup"
+
+	// OpDo is the name of calls made with Do.
+	OpDo = "Do"
+)
+
+// Operation describes an API call as seen by middleware and hooks.
+type Operation struct {
+	// Name identifies the call, e.g. OpSendTransactional.
+	Name   string
+	Method string
+	URL    string
+	// Request is the value encoded as the request body, or nil. Middleware may replace it.
+	Request interface{}
+	// Header holds extra headers sent with every attempt of the call.
+	Header http.Header
+	// Attempts is the number of HTTP requests made so far.
+	Attempts int
+	// StatusCode and RequestID are taken from the last response, if any.
+	StatusCode int
+	RequestID  string
+	// Cached reports that the result was served by Client.Cache without a
+	// request of its own. StatusCode and RequestID are then those of the
+	// request that produced the cached result, and Attempts is zero.
+	Cached bool
+
+	// credentials holds the headers set by the Authenticator when Client.Cache
+	// keyed the call. The first attempt sends them instead of authenticating again.
+	credentials http.Header
+}
+
+// Handler performs an API call and decodes the response body into response.
+type Handler func(ctx context.Context, op *Operation, response interface{}) error
+
+// Middleware wraps a Handler to add behavior around every API call, e.g. headers,
+// audit logging or metrics. It may inspect and change op before calling next, and
+// inspect the response and error afterwards.
+//
+//	client.Middleware = append(client.Middleware, func(next mepost.Handler) mepost.Handler {
+//		return func(ctx context.Context, op *mepost.Operation, response interface{}) error {
+//			op.Header.Set("X-Tenant", tenantFrom(ctx))
+//			return next(ctx, op, response)
+//		}
+//	})
+type Middleware func(next Handler) Handler
+
+// Hooks are functions called during every API call. Any of them may be nil.
+// They run inside the middleware chain, around the retry loop, and are not
+// called for results served by Client.Cache.
+type Hooks struct {
+	// BeforeRequest is called before the first attempt. Returning an error aborts the call.
+	BeforeRequest func(ctx context.Context, op *Operation) error
+	// AfterResponse is called when the call succeeds, with the decoded response
+	// and the time taken by all attempts.
+	AfterResponse func(ctx context.Context, op *Operation, response interface{}, elapsed time.Duration)
+	// OnError is called when the call fails, with the time taken by all attempts.
+	OnError func(ctx context.Context, op *Operation, err error, elapsed time.Duration)
+	// OnRetry is called before a failed attempt is retried. attempt counts from 1.
+	OnRetry func(ctx context.Context, op *Operation, attempt int, err error, wait time.Duration)
+}
+
+// chain wraps h in the client's middleware, the first being the outermost.
+func (c *Client) chain(h Handler) Handler {
+	for i := len(c.Middleware) - 1; i >= 0; i-- {
+		h = c.Middleware[i](h)
+	}
+	return h
+}
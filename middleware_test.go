@@ -0,0 +1,156 @@
+package mepost
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyServer fails the first failures requests with 503 Service Unavailable
+// and answers the rest with one IP address, or a schedule for POST requests.
+// Every response carries a request ID counting from req-1. It records the X-Tenant header of each request.
+func flakyServer(failures int, tenants *[]string) *httptest.Server {
+	var requests atomic.Int32
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := requests.Add(1)
+		*tenants = append(*tenants, r.Header.Get("X-Tenant"))
+		w.Header().Set("X-Request-Id", fmt.Sprint("req-", n))
+		if int(n) <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.Method == http.MethodPost {
+			w.Write([]byte(`{"uuid":"s1","jobStatus":"SCHEDULED"}`))
+			return
+		}
+		w.Write([]byte(`[{"ip":"192.0.2.1"}]`))
+	}))
+}
+
+// tracingClient returns a client whose middleware and hooks append what they
+// see to events.
+func tracingClient(url string, events *[]string) *Client {
+	c := NewClient("key")
+	c.BaseURL = url
+	c.Retry = RetryPolicy{MaxRetries: 1, MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+	for _, name := range []string{"outer", "inner"} {
+		name := name
+		c.Middleware = append(c.Middleware, func(next Handler) Handler {
+			return func(ctx context.Context, op *Operation, response interface{}) error {
+				*events = append(*events, name+" before")
+				op.Header.Set("X-Tenant", op.Header.Get("X-Tenant")+name+";")
+				err := next(ctx, op, response)
+				*events = append(*events, fmt.Sprintf("%s after: %s attempts=%d status=%d id=%s err=%v",
+					name, op.Name, op.Attempts, op.StatusCode, op.RequestID, err != nil))
+				return err
+			}
+		})
+	}
+	c.Hooks = Hooks{
+		BeforeRequest: func(ctx context.Context, op *Operation) error {
+			*events = append(*events, fmt.Sprintf("before request: attempts=%d", op.Attempts))
+			return nil
+		},
+		AfterResponse: func(ctx context.Context, op *Operation, response interface{}, elapsed time.Duration) {
+			ips := *response.(*[]IpAddress)
+			*events = append(*events, fmt.Sprintf("after response: %d IPs", len(ips)))
+		},
+		OnError: func(ctx context.Context, op *Operation, err error, elapsed time.Duration) {
+			*events = append(*events, fmt.Sprintf("on error: %v", err))
+		},
+		OnRetry: func(ctx context.Context, op *Operation, attempt int, err error, wait time.Duration) {
+			*events = append(*events, fmt.Sprintf("on retry %d: status=%d id=%s", attempt, op.StatusCode, op.RequestID))
+		},
+	}
+	return c
+}
+
+func TestMiddlewareAndHooks(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int
+		events   []string
+	}{
+		{"first attempt", 0, []string{
+			"outer before",
+			"inner before",
+			"before request: attempts=0",
+			"after response: 1 IPs",
+			"inner after: ListIPs attempts=1 status=200 id=req-1 err=false",
+			"outer after: ListIPs attempts=1 status=200 id=req-1 err=false",
+		}},
+		{"retried", 1, []string{
+			"outer before",
+			"inner before",
+			"before request: attempts=0",
+			"on retry 1: status=503 id=req-1",
+			"after response: 1 IPs",
+			"inner after: ListIPs attempts=2 status=200 id=req-2 err=false",
+			"outer after: ListIPs attempts=2 status=200 id=req-2 err=false",
+		}},
+		{"failed", 2, []string{
+			"outer before",
+			"inner before",
+			"before request: attempts=0",
+			"on retry 1: status=503 id=req-1",
+			"on error: mepost: 503 Service Unavailable",
+			"inner after: ListIPs attempts=2 status=503 id=req-2 err=true",
+			"outer after: ListIPs attempts=2 status=503 id=req-2 err=true",
+		}},
+	}
+	for _, tt := range tests {
+		var events, tenants []string
+		srv := flakyServer(tt.failures, &tenants)
+		c := tracingClient(srv.URL, &events)
+		_, err := c.Outbound.IPs.List(context.Background())
+		srv.Close()
+		if (err != nil) != (tt.failures > c.Retry.MaxRetries) {
+			t.Errorf("%s: got error %v", tt.name, err)
+		}
+		if got, want := strings.Join(events, "\n"), strings.Join(tt.events, "\n"); got != want {
+			t.Errorf("%s: got events\n%s\nwant\n%s", tt.name, got, want)
+		}
+		// Headers set by middleware are sent with every attempt.
+		for i, tenant := range tenants {
+			if tenant != "outer;inner;" {
+				t.Errorf("%s: attempt %d sent X-Tenant %q", tt.name, i+1, tenant)
+			}
+		}
+	}
+}
+
+func TestBeforeRequestAbort(t *testing.T) {
+	var events, tenants []string
+	srv := flakyServer(0, &tenants)
+	defer srv.Close()
+	c := tracingClient(srv.URL, &events)
+	errDenied := errors.New("denied")
+	c.Hooks.BeforeRequest = func(ctx context.Context, op *Operation) error {
+		events = append(events, "before request: denied")
+		return errDenied
+	}
+
+	if _, err := c.Outbound.IPs.List(context.Background()); !errors.Is(err, errDenied) {
+		t.Errorf("got error %v, want the hook's error", err)
+	}
+	if len(tenants) != 0 {
+		t.Errorf("made %d requests after BeforeRequest failed", len(tenants))
+	}
+	// Neither OnError nor AfterResponse is called for an aborted call.
+	want := []string{
+		"outer before",
+		"inner before",
+		"before request: denied",
+		"inner after: ListIPs attempts=0 status=0 id= err=true",
+		"outer after: ListIPs attempts=0 status=0 id= err=true",
+	}
+	if got := strings.Join(events, "\n"); got != strings.Join(want, "\n") {
+		t.Errorf("got events\n%s\nwant\n%s", got, strings.Join(want, "\n"))
+	}
+}
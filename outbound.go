@@ -0,0 +1,107 @@
+package mepost
+
+import "context"
+
+// OutboundService manages outbound IP addresses and IP groups.
+type OutboundService struct {
+	IPs      *IPsService
+	IPGroups *IPGroupsService
+}
+
+// IPsService manages outbound IP addresses and their warm-up.
+type IPsService struct {
+	client *Client
+}
+
+// List retrieves all outbound IP addresses.
+func (s *IPsService) List(ctx context.Context) ([]IpAddress, error) {
+	url, err := s.client.buildURL(nil, "outbound", "ip", "list")
+	if err != nil {
+		return nil, err
+	}
+	response := []IpAddress{}
+	err = s.client.makeRequest(ctx, OpListIPs, "GET", url, nil, &response)
+	return response, err
+}
+
+// Get retrieves an outbound IP address.
+func (s *IPsService) Get(ctx context.Context, ip string) (*IpAddress, error) {
+	url, err := s.client.buildURL(nil, "outbound", "ip", "info", ip)
+	if err != nil {
+		return nil, err
+	}
+	response := &IpAddress{}
+	err = s.client.makeRequest(ctx, OpGetIP, "GET", url, nil, response)
+	return response, err
+}
+
+// SetGroup assigns an IP address to an IP group.
+func (s *IPsService) SetGroup(ctx context.Context, request SetIpGroupRequest) (*SetIpGroupResponse, error) {
+	url, err := s.client.buildURL(nil, "outbound", "ip", "set-ip-group")
+	if err != nil {
+		return nil, err
+	}
+	response := &SetIpGroupResponse{}
+	err = s.client.makeRequest(ctx, OpSetIPGroup, "POST", url, request, response)
+	return response, err
+}
+
+// StartWarmup starts the warm-up of an IP address.
+func (s *IPsService) StartWarmup(ctx context.Context, request StartWarmUpRequest) (*StartWarmUpResponse, error) {
+	url, err := s.client.buildURL(nil, "outbound", "ip", "start-warmup")
+	if err != nil {
+		return nil, err
+	}
+	response := &StartWarmUpResponse{}
+	err = s.client.makeRequest(ctx, OpStartWarmup, "POST", url, request, response)
+	return response, err
+}
+
+// CancelWarmup cancels the warm-up of an IP address.
+func (s *IPsService) CancelWarmup(ctx context.Context, request CancelWarmUpRequest) (*CancelWarmUpResponse, error) {
+	url, err := s.client.buildURL(nil, "outbound", "ip", "cancel-warmup")
+	if err != nil {
+		return nil, err
+	}
+	response := &CancelWarmUpResponse{}
+	err = s.client.makeRequest(ctx, OpCancelWarmup, "POST", url, request, response)
+	return response, err
+}
+
+// IPGroupsService manages IP groups.
+type IPGroupsService struct {
+	client *Client
+}
+
+// List retrieves all IP groups.
+func (s *IPGroupsService) List(ctx context.Context) ([]IPGroup, error) {
+	url, err := s.client.buildURL(nil, "outbound", "ip-group", "list")
+	if err != nil {
+		return nil, err
+	}
+	response := []IPGroup{}
+	err = s.client.makeRequest(ctx, OpListIPGroups, "GET", url, nil, &response)
+	return response, err
+}
+
+// Get retrieves an IP group by name.
+func (s *IPGroupsService) Get(ctx context.Context, name string) (*IPGroup, error) {
+	url, err := s.client.buildURL(nil, "outbound", "ip-group", "info", name)
+	if err != nil {
+		return nil, err
+	}
+	response := &IPGroup{}
+	err = s.client.makeRequest(ctx, OpGetIPGroup, "GET", url, nil, response)
+	return response, err
+}
+
+// Create creates a new IP group.
+func (s *IPGroupsService) Create(ctx context.Context, request CreateIpGroupRequest) (*IPGroup, error) {
+	url, err := s.client.buildURL(nil, "outbound", "ip-group", "create")
+	if err != nil {
+		return nil, err
+	}
+	response := &IPGroup{}
+	err = s.client.makeRequest(ctx, OpCreateIPGroup, "POST", url, request, response)
+	return response, err
+}
@@ -0,0 +1,286 @@
+package mepost
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EndpointClass groups operations that share a rate limit and circuit breaker.
+type EndpointClass string
+
+// Endpoint classes.
+const (
+	// ClassSend covers the operations that send messages.
+	ClassSend EndpointClass = "send"
+	// ClassManagement covers all other operations, including calls made with Do.
+	ClassManagement EndpointClass = "management"
+)
+
+// Class returns the endpoint class of the operation.
+func (op *Operation) Class() EndpointClass {
+	if strings.HasPrefix(op.Name, "Send") {
+		return ClassSend
+	}
+	return ClassManagement
+}
+
+// ErrRateLimited is returned, wrapped, when a call is rejected by the client-side
+// rate limiter in fail-fast mode.
+var ErrRateLimited = errors.New("mepost: rate limit exceeded")
+
+// RateLimit configures a token bucket.
+type RateLimit struct {
+	// Rate is the number of requests allowed per second. Zero disables the limit.
+	Rate float64
+	// Burst is the number of requests that may be made at once. It is at least 1.
+	Burst int
+}
+
+// PlanRateLimit returns a send limit that spreads the daily limit of a pricing
+// plan evenly over the day, allowing up to an hour's worth of requests at once.
+// Each request counts once, whatever its number of recipients.
+func PlanRateLimit(plan PricingPlan) RateLimit {
+	if plan.DailyLimit <= 0 {
+		return RateLimit{}
+	}
+	burst := plan.DailyLimit / 24
+	if burst < 1 {
+		burst = 1
+	}
+	return RateLimit{Rate: float64(plan.DailyLimit) / (24 * 60 * 60), Burst: burst}
+}
+
+// RateLimitPolicy configures a RateLimiter.
+type RateLimitPolicy struct {
+	// Send limits the operations that send messages.
+	Send RateLimit
+	// Management limits all other operations.
+	Management RateLimit
+	// FailFast makes calls fail with ErrRateLimited instead of waiting for the limit.
+	FailFast bool
+}
+
+// RateLimiter paces requests with a token bucket per endpoint class. It also
+// pauses a class when the API responds with 429 Too Many Requests or reports
+// through rate-limit headers that no requests remain.
+//
+// A RateLimiter may be shared by several clients using the same API key.
+type RateLimiter struct {
+	failFast bool
+	now      func() time.Time
+
+	mu      sync.Mutex
+	buckets map[EndpointClass]*bucket
+}
+
+// NewRateLimiter creates a RateLimiter.
+func NewRateLimiter(policy RateLimitPolicy) *RateLimiter {
+	now := time.Now()
+	return &RateLimiter{
+		failFast: policy.FailFast,
+		now:      time.Now,
+		buckets: map[EndpointClass]*bucket{
+			ClassSend:       newBucket(policy.Send, now),
+			ClassManagement: newBucket(policy.Management, now),
+		},
+	}
+}
+
+// SetLimit changes the limit of an endpoint class, e.g. after fetching the pricing plan.
+func (l *RateLimiter) SetLimit(class EndpointClass, limit RateLimit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b := l.buckets[class]
+	if b == nil {
+		l.buckets[class] = newBucket(limit, l.now())
+		return
+	}
+	b.limit = normalizeLimit(limit)
+	b.tokens = math.Min(b.tokens, float64(b.limit.Burst))
+}
+
+// Wait takes a token for the endpoint class, waiting until one is available
+// or ctx is done. In fail-fast mode it returns an error wrapping ErrRateLimited instead of waiting.
+func (l *RateLimiter) Wait(ctx context.Context, class EndpointClass) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	b := l.buckets[class]
+	if b == nil {
+		l.mu.Unlock()
+		return nil
+	}
+	wait, ok := b.take(l.now(), !l.failFast)
+	l.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: %s requests are limited for another %s", ErrRateLimited, class, wait.Round(time.Millisecond))
+	}
+	if wait <= 0 {
+		return nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+		l.release(b)
+		return fmt.Errorf("%w: %s requests are limited for another %s, beyond the context deadline", ErrRateLimited, class, wait.Round(time.Millisecond))
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.release(b)
+		return ctx.Err()
+	}
+}
+
+// release returns a token taken by Wait that was not used.
+func (l *RateLimiter) release(b *bucket) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if b.limit.Rate > 0 {
+		b.tokens = math.Min(float64(b.limit.Burst), b.tokens+1)
+	}
+}
+
+// observe adapts the limiter to a response from the API.
+func (l *RateLimiter) observe(class EndpointClass, status int, header http.Header) {
+	if l == nil {
+		return
+	}
+	now := l.now()
+	info := parseRateLimitHeaders(header, now)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b := l.buckets[class]
+	if b == nil {
+		return
+	}
+	b.refill(now)
+	if status == http.StatusTooManyRequests {
+		wait, ok := retryAfter(header)
+		if !ok {
+			wait = info.Reset.Sub(now)
+		}
+		if wait <= 0 {
+			wait = time.Second
+		}
+		b.pause(now.Add(wait))
+		return
+	}
+	if info.HasRemaining {
+		if info.Remaining == 0 && info.Reset.After(now) {
+			b.pause(info.Reset)
+		} else if float64(info.Remaining) < b.tokens {
+			b.tokens = float64(info.Remaining)
+		}
+	}
+}
+
+// RateLimitInfo holds the values of the rate-limit headers of a response.
+// Fields are zero if the corresponding header is absent.
+type RateLimitInfo struct {
+	// Limit is the number of requests allowed in the current window.
+	Limit int
+	// Remaining is the number of requests left in the current window.
+	Remaining int
+	// HasRemaining reports whether the response included the remaining count.
+	HasRemaining bool
+	// Reset is when the window resets.
+	Reset time.Time
+}
+
+// parseRateLimitHeaders reads the X-RateLimit-* or RateLimit-* headers. The
+// reset may be given in seconds from now or as a Unix time.
+func parseRateLimitHeaders(header http.Header, now time.Time) RateLimitInfo {
+	var info RateLimitInfo
+	get := func(name string) string {
+		if v := header.Get("X-RateLimit-" + name); v != "" {
+			return v
+		}
+		return header.Get("RateLimit-" + name)
+	}
+	if n, err := strconv.Atoi(get("Limit")); err == nil {
+		info.Limit = n
+	}
+	if n, err := strconv.Atoi(get("Remaining")); err == nil && n >= 0 {
+		info.Remaining, info.HasRemaining = n, true
+	}
+	if n, err := strconv.ParseInt(get("Reset"), 10, 64); err == nil && n >= 0 {
+		// Values that cannot be a delay are Unix times.
+		if n > 1e9 {
+			info.Reset = time.Unix(n, 0)
+		} else {
+			info.Reset = now.Add(time.Duration(n) * time.Second)
+		}
+	}
+	return info
+}
+
+type bucket struct {
+	limit       RateLimit
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+}
+
+func newBucket(limit RateLimit, now time.Time) *bucket {
+	limit = normalizeLimit(limit)
+	return &bucket{limit: limit, tokens: float64(limit.Burst), last: now}
+}
+
+func normalizeLimit(limit RateLimit) RateLimit {
+	if limit.Burst < 1 {
+		limit.Burst = 1
+	}
+	return limit
+}
+
+func (b *bucket) refill(now time.Time) {
+	if b.limit.Rate > 0 && now.After(b.last) {
+		b.tokens = math.Min(float64(b.limit.Burst), b.tokens+now.Sub(b.last).Seconds()*b.limit.Rate)
+	}
+	b.last = now
+}
+
+// take takes a token and returns how long the caller must wait before using it.
+// Unless reserve is set, it takes nothing and returns false if the caller would have to wait.
+func (b *bucket) take(now time.Time, reserve bool) (time.Duration, bool) {
+	var wait time.Duration
+	if now.Before(b.pausedUntil) {
+		wait = b.pausedUntil.Sub(now)
+	}
+	if b.limit.Rate > 0 {
+		b.refill(now)
+		if b.tokens < 1 {
+			deficit := time.Duration((1 - b.tokens) / b.limit.Rate * float64(time.Second))
+			if deficit > wait {
+				wait = deficit
+			}
+		}
+	}
+	if wait > 0 && !reserve {
+		return wait, false
+	}
+	if b.limit.Rate > 0 {
+		b.tokens--
+	}
+	return wait, true
+}
+
+// pause stops handing out tokens until t.
+func (b *bucket) pause(t time.Time) {
+	if t.After(b.pausedUntil) {
+		b.pausedUntil = t
+	}
+	if b.tokens > 0 {
+		b.tokens = 0
+	}
+}
@@ -0,0 +1,176 @@
+package mepost
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testLimiter returns a rate limiter on a fake clock.
+func testLimiter(policy RateLimitPolicy) (*RateLimiter, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)}
+	l := NewRateLimiter(policy)
+	l.now = clock.now
+	for _, b := range l.buckets {
+		b.last = clock.t
+	}
+	return l, clock
+}
+
+// takeAll takes tokens until the limiter refuses one and returns how many it got.
+func takeAll(t *testing.T, l *RateLimiter, class EndpointClass) int {
+	t.Helper()
+	for n := 0; n < 100; n++ {
+		if err := l.Wait(context.Background(), class); err != nil {
+			if !errors.Is(err, ErrRateLimited) {
+				t.Fatalf("got error %v, want ErrRateLimited", err)
+			}
+			return n
+		}
+	}
+	t.Fatal("limiter never ran out of tokens")
+	return 0
+}
+
+func TestRateLimiterBurstAndRefill(t *testing.T) {
+	l, clock := testLimiter(RateLimitPolicy{Send: RateLimit{Rate: 2, Burst: 3}, FailFast: true})
+
+	if n := takeAll(t, l, ClassSend); n != 3 {
+		t.Errorf("got a burst of %d, want 3", n)
+	}
+	// Tokens come back at the rate, one every half second.
+	clock.advance(500 * time.Millisecond)
+	if n := takeAll(t, l, ClassSend); n != 1 {
+		t.Errorf("got %d tokens after 500ms, want 1", n)
+	}
+	clock.advance(1200 * time.Millisecond)
+	if n := takeAll(t, l, ClassSend); n != 2 {
+		t.Errorf("got %d tokens after 1.2s, want 2", n)
+	}
+	// The bucket never holds more than the burst.
+	clock.advance(time.Hour)
+	if n := takeAll(t, l, ClassSend); n != 3 {
+		t.Errorf("got %d tokens after an hour, want the burst of 3", n)
+	}
+	// Classes have their own buckets, and a zero rate is unlimited.
+	for i := 0; i < 10; i++ {
+		if err := l.Wait(context.Background(), ClassManagement); err != nil {
+			t.Fatalf("management request %d: %v", i, err)
+		}
+	}
+
+	l.SetLimit(ClassSend, RateLimit{Rate: 1, Burst: 1})
+	clock.advance(time.Hour)
+	if n := takeAll(t, l, ClassSend); n != 1 {
+		t.Errorf("got %d tokens after lowering the burst, want 1", n)
+	}
+}
+
+func TestRateLimiterWait(t *testing.T) {
+	l, _ := testLimiter(RateLimitPolicy{Send: RateLimit{Rate: 50, Burst: 1}})
+	ctx := context.Background()
+	if err := l.Wait(ctx, ClassSend); err != nil {
+		t.Fatal(err)
+	}
+	// The next token is 20ms away, which Wait sleeps through.
+	start := time.Now()
+	if err := l.Wait(ctx, ClassSend); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 15*time.Millisecond {
+		t.Errorf("Wait returned after %s, want about 20ms", elapsed)
+	}
+}
+
+func TestRateLimiterWaitCanceled(t *testing.T) {
+	l, clock := testLimiter(RateLimitPolicy{Send: RateLimit{Rate: 1, Burst: 1}})
+	if err := l.Wait(context.Background(), ClassSend); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	if err := l.Wait(ctx, ClassSend); !errors.Is(err, context.Canceled) {
+		t.Fatalf("got error %v while waiting with a canceled context, want context.Canceled", err)
+	}
+	// A deadline before the next token fails at once.
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx, ClassSend); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("got error %v with a deadline before the next token, want ErrRateLimited", err)
+	}
+
+	// Abandoned waits give their tokens back, so the next token is due after a second.
+	clock.advance(time.Second)
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.Wait(canceled, ClassSend); err != nil {
+		t.Errorf("got error %v, want the token refilled after a second", err)
+	}
+}
+
+func TestRateLimiterObserve(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		header http.Header
+		paused time.Duration
+	}{
+		{"ok", http.StatusOK, http.Header{"X-Ratelimit-Remaining": {"5"}}, 0},
+		{"retry after", http.StatusTooManyRequests, http.Header{"Retry-After": {"30"}}, 30 * time.Second},
+		{"reset", http.StatusTooManyRequests, http.Header{"Ratelimit-Reset": {"10"}}, 10 * time.Second},
+		{"no hint", http.StatusTooManyRequests, nil, time.Second},
+		{"none remaining", http.StatusOK, http.Header{"X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset": {"20"}}, 20 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, clock := testLimiter(RateLimitPolicy{Send: RateLimit{Rate: 100, Burst: 10}, FailFast: true})
+			l.observe(ClassSend, tt.status, tt.header)
+			if tt.paused == 0 {
+				if n := takeAll(t, l, ClassSend); n != 5 {
+					t.Errorf("got %d tokens, want the 5 the API reported", n)
+				}
+				return
+			}
+			clock.advance(tt.paused - time.Millisecond)
+			if err := l.Wait(context.Background(), ClassSend); !errors.Is(err, ErrRateLimited) {
+				t.Errorf("got error %v before the pause ended, want ErrRateLimited", err)
+			}
+			clock.advance(time.Millisecond)
+			if err := l.Wait(context.Background(), ClassSend); err != nil {
+				t.Errorf("got error %v after the pause ended", err)
+			}
+		})
+	}
+}
+
+// TestRateLimiterCompressionRejected checks that the uncompressed request sent
+// after a 415 takes a token too.
+func TestRateLimiterCompressionRejected(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("Content-Encoding") != "" {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		w.Write([]byte(`{"uuid":"s1"}`))
+	}))
+	defer srv.Close()
+	c := NewClient("key")
+	c.BaseURL = srv.URL
+	c.Compression.Threshold = 1 << 10
+	c.RateLimiter = NewRateLimiter(RateLimitPolicy{Send: RateLimit{Rate: 0.001, Burst: 1}, FailFast: true})
+
+	_, err := c.Messages.SendMarketing(context.Background(), marketingRequest(1000))
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("got error %v, want ErrRateLimited for the uncompressed request", err)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("server got %d requests, want 1", n)
+	}
+}
@@ -0,0 +1,432 @@
+// Package recorder provides an http.RoundTripper that records Mepost API
+// interactions to a cassette file and replays them, so that integration tests
+// can run offline and deterministically.
+//
+// Record once against the real API, then replay in CI:
+//
+//	rec, err := recorder.New("testdata/send.json", recorder.ModeFromEnv())
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	defer rec.Stop()
+//
+//	client := mepost.NewClient(os.Getenv("MEPOST_API_KEY"))
+//	client.HTTPClient = rec.Client()
+//
+// The Authorization header is never written to the cassette, and recipient
+// addresses in paths, queries and JSON bodies are replaced by stable placeholders.
+// Requests are matched on method, path, query and normalized JSON body.
+package recorder
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Mode selects how a Recorder handles requests.
+type Mode int
+
+const (
+	// ModeReplay serves requests from the cassette and fails on unmatched requests.
+	ModeReplay Mode = iota
+	// ModeRecord sends requests to the API and records them, replacing the cassette on Stop.
+	ModeRecord
+	// ModePassthrough sends requests to the API without recording them.
+	ModePassthrough
+)
+
+// ModeEnv is the environment variable read by ModeFromEnv.
+const ModeEnv = "MEPOST_RECORDER_MODE"
+
+// ModeFromEnv returns the mode named by the MEPOST_RECORDER_MODE environment
+// variable ("record", "replay" or "passthrough"), defaulting to ModeReplay.
+func ModeFromEnv() Mode {
+	switch strings.ToLower(os.Getenv(ModeEnv)) {
+	case "record":
+		return ModeRecord
+	case "passthrough":
+		return ModePassthrough
+	}
+	return ModeReplay
+}
+
+func (m Mode) String() string {
+	switch m {
+	case ModeReplay:
+		return "replay"
+	case ModeRecord:
+		return "record"
+	case ModePassthrough:
+		return "passthrough"
+	}
+	return fmt.Sprintf("Mode(%d)", int(m))
+}
+
+// Cassette is the on-disk format of recorded interactions.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a recorded request and its response.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is a redacted request.
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	Path   string      `json:"path"`
+	Query  url.Values  `json:"query,omitempty"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// RecordedResponse is a redacted response.
+type RecordedResponse struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// ErrNoInteraction is returned in replay mode when no recorded interaction matches a request.
+var ErrNoInteraction = errors.New("recorder: no recorded interaction matches the request")
+
+// Recorder is an http.RoundTripper that records and replays interactions.
+type Recorder struct {
+	// Transport is used to reach the API in record and passthrough modes.
+	// If nil, http.DefaultTransport is used.
+	Transport http.RoundTripper
+
+	mode Mode
+	path string
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// New creates a Recorder for the cassette at path. In replay mode the cassette
+// must exist; in record mode it is created or replaced when Stop is called.
+func New(path string, mode Mode) (*Recorder, error) {
+	r := &Recorder{mode: mode, path: path}
+	if mode != ModeReplay {
+		return r, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("recorder: error reading cassette: %v", err)
+	}
+	if err := json.Unmarshal(data, &r.cassette); err != nil {
+		return nil, fmt.Errorf("recorder: error parsing cassette %s: %v", path, err)
+	}
+	r.used = make([]bool, len(r.cassette.Interactions))
+	return r, nil
+}
+
+// Mode returns the mode of the recorder.
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// Client returns an http.Client that uses the recorder as its transport.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Stop writes the recorded interactions to the cassette in record mode.
+// It does nothing in the other modes.
+func (r *Recorder) Stop() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return fmt.Errorf("recorder: error encoding cassette: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("recorder: error writing cassette: %v", err)
+	}
+	if err := os.WriteFile(r.path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("recorder: error writing cassette: %v", err)
+	}
+	return nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	switch r.mode {
+	case ModeRecord:
+		return r.record(req)
+	case ModePassthrough:
+		return r.transport().RoundTrip(req)
+	}
+	return r.replay(req)
+}
+
+func (r *Recorder) transport() http.RoundTripper {
+	if r.Transport != nil {
+		return r.Transport
+	}
+	return http.DefaultTransport
+}
+
+func (r *Recorder) record(req *http.Request) (*http.Response, error) {
+	body, err := readAndRestore(&req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("recorder: error reading request body: %v", err)
+	}
+	resp, err := r.transport().RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := readAndRestore(&resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("recorder: error reading response body: %v", err)
+	}
+
+	// The body is normalized before it is stored, so its recorded length may differ.
+	header := redactHeader(resp.Header)
+	header.Del("Content-Length")
+	if isGzip(resp.Header) {
+		respBody = gunzip(respBody)
+		header.Del("Content-Encoding")
+	}
+	interaction := Interaction{
+		Request: newRecordedRequest(req, body),
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     header,
+			Body:       normalizeBody(respBody),
+		},
+	}
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	r.mu.Unlock()
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request) (*http.Response, error) {
+	body, err := readAndRestore(&req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("recorder: error reading request body: %v", err)
+	}
+	want := newRecordedRequest(req, body)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// Prefer interactions that have not been replayed yet, so that repeated
+	// identical requests receive their responses in recorded order.
+	found := -1
+	for i, interaction := range r.cassette.Interactions {
+		if !matches(interaction.Request, want) {
+			continue
+		}
+		if !r.used[i] {
+			found = i
+			break
+		}
+		if found == -1 {
+			found = i
+		}
+	}
+	if found == -1 {
+		return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, want.Method, want.Path)
+	}
+	r.used[found] = true
+
+	recorded := r.cassette.Interactions[found].Response
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+		StatusCode:    recorded.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        recorded.Header.Clone(),
+		Body:          io.NopCloser(strings.NewReader(recorded.Body)),
+		ContentLength: int64(len(recorded.Body)),
+		Request:       req,
+	}, nil
+}
+
+func newRecordedRequest(req *http.Request, body []byte) RecordedRequest {
+	query := url.Values{}
+	for key, values := range req.URL.Query() {
+		for _, value := range values {
+			query.Add(key, redactString(value))
+		}
+	}
+	if len(query) == 0 {
+		query = nil
+	}
+	return RecordedRequest{
+		Method: req.Method,
+		Path:   redactPath(req.URL.EscapedPath()),
+		Query:  query,
+		Header: redactHeader(req.Header),
+		Body:   normalizeBody(requestBody(req.Header, body)),
+	}
+}
+
+// requestBody returns body decompressed if the request was sent gzipped, so
+// that compressed and uncompressed requests are recorded and matched alike.
+func requestBody(header http.Header, body []byte) []byte {
+	if isGzip(header) {
+		return gunzip(body)
+	}
+	return body
+}
+
+func isGzip(header http.Header) bool {
+	return strings.EqualFold(header.Get("Content-Encoding"), "gzip")
+}
+
+// gunzip decompresses data, returning it unchanged if it is not valid gzip.
+func gunzip(data []byte) []byte {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return data
+	}
+	plain, err := io.ReadAll(zr)
+	if err != nil {
+		return data
+	}
+	return plain
+}
+
+func matches(recorded, req RecordedRequest) bool {
+	if recorded.Method != req.Method || recorded.Path != req.Path || recorded.Body != req.Body {
+		return false
+	}
+	if len(recorded.Query) != len(req.Query) {
+		return false
+	}
+	for key, values := range recorded.Query {
+		other := req.Query[key]
+		if len(values) != len(other) {
+			return false
+		}
+		a := append([]string(nil), values...)
+		b := append([]string(nil), other...)
+		sort.Strings(a)
+		sort.Strings(b)
+		for i := range a {
+			if a[i] != b[i] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func readAndRestore(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+	data, err := io.ReadAll(*body)
+	(*body).Close()
+	if err != nil {
+		return nil, err
+	}
+	*body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+// sensitiveHeaders are dropped from recorded interactions.
+var sensitiveHeaders = []string{"Authorization", "Cookie", "Set-Cookie"}
+
+func redactHeader(header http.Header) http.Header {
+	redacted := header.Clone()
+	for _, name := range sensitiveHeaders {
+		redacted.Del(name)
+	}
+	if len(redacted) == 0 {
+		return nil
+	}
+	return redacted
+}
+
+// recipientKeys are the JSON keys whose values hold recipient addresses.
+var recipientKeys = map[string]bool{
+	"to":           true,
+	"email":        true,
+	"emails":       true,
+	"emailAddress": true,
+}
+
+var emailPattern = regexp.MustCompile(`^[^@\s/]+@[^@\s/]+$`)
+
+// redactString replaces an email address with a placeholder derived from its
+// hash, so that the same address always produces the same placeholder.
+func redactString(s string) string {
+	if !emailPattern.MatchString(s) {
+		return s
+	}
+	sum := sha256.Sum256([]byte(strings.ToLower(s)))
+	return "redacted-" + hex.EncodeToString(sum[:4]) + "@example.com"
+}
+
+func redactPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if unescaped, err := url.PathUnescape(segment); err == nil {
+			if redacted := redactString(unescaped); redacted != unescaped {
+				segments[i] = url.PathEscape(redacted)
+			}
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// normalizeBody redacts recipient addresses in a JSON body and re-encodes it
+// with sorted keys and no insignificant whitespace. Other bodies are returned as is.
+func normalizeBody(body []byte) string {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return ""
+	}
+	var v interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&v); err != nil {
+		return string(body)
+	}
+	data, err := json.Marshal(redactJSON(v, false))
+	if err != nil {
+		return string(body)
+	}
+	return string(data)
+}
+
+func redactJSON(v interface{}, recipient bool) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for key, child := range value {
+			value[key] = redactJSON(child, recipient || recipientKeys[key])
+		}
+	case []interface{}:
+		for i, child := range value {
+			value[i] = redactJSON(child, recipient)
+		}
+	case string:
+		if recipient {
+			return redactString(value)
+		}
+	}
+	return v
+}
@@ -0,0 +1,235 @@
+package recorder
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	mepost "github.com/mepost-io/golang-sdk"
+)
+
+const apiKey = "sk_live_0123456789abcdef"
+
+// apiServer answers subscriber lookups and transactional sends, counting the requests.
+func apiServer(requests *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := requests.Add(1)
+		w.Header().Set("X-Request-Id", fmt.Sprint("req-", n))
+		w.Header().Set("Set-Cookie", "session="+apiKey)
+		if r.Method == http.MethodPost {
+			fmt.Fprintf(w, `{"uuid":"s%d","jobStatus":"SCHEDULED"}`, n)
+			return
+		}
+		parts := strings.Split(r.URL.Path, "/")
+		fmt.Fprintf(w, `{"emailAddress":%q,"confirmed":true}`, parts[len(parts)-1])
+	}))
+}
+
+// calls makes the SDK calls recorded and replayed by TestRecordReplay.
+func calls(t *testing.T, client *mepost.Client) (*mepost.Subscriber, []*mepost.Schedule) {
+	t.Helper()
+	ctx := context.Background()
+	subscriber, err := client.Subscribers.Get(ctx, "g1", "alice@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schedules []*mepost.Schedule
+	for i := 0; i < 2; i++ {
+		schedule, err := client.Messages.SendTransactional(ctx, mepost.SendTransactionalRequest{
+			FromEmail: "noreply@example.com",
+			Subject:   "Receipt",
+			Text:      "Thanks",
+			To:        []mepost.To{{Email: "alice@example.com", Name: "Alice"}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		schedules = append(schedules, schedule)
+	}
+	return subscriber, schedules
+}
+
+func TestRecordReplay(t *testing.T) {
+	var requests atomic.Int32
+	srv := apiServer(&requests)
+	path := filepath.Join(t.TempDir(), "testdata", "cassette.json")
+
+	rec, err := New(path, ModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := mepost.NewClient(apiKey)
+	client.BaseURL = srv.URL + "/v1"
+	client.HTTPClient = rec.Client()
+	recorded, recordedSchedules := calls(t, client)
+	if err := rec.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	srv.Close()
+	if recorded.EmailAddress != "alice@example.com" {
+		t.Errorf("recording returned subscriber %q, want the real response", recorded.EmailAddress)
+	}
+
+	cassette, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{apiKey, "alice@example.com", "Authorization", "Set-Cookie"} {
+		if bytes.Contains(cassette, []byte(secret)) {
+			t.Errorf("cassette contains %q:\n%s", secret, cassette)
+		}
+	}
+
+	// The server is gone, so every response comes from the cassette.
+	rec, err = New(path, ModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client = mepost.NewClient("another-key")
+	client.BaseURL = "https://api.invalid/v1"
+	client.HTTPClient = rec.Client()
+	replayed, replayedSchedules := calls(t, client)
+	if want := redactString("alice@example.com"); replayed.EmailAddress != want || !replayed.Confirmed {
+		t.Errorf("replay returned subscriber %+v, want the redacted recording", replayed)
+	}
+	for i := range replayedSchedules {
+		if got, want := replayedSchedules[i].UUID, recordedSchedules[i].UUID; got != want {
+			t.Errorf("send %d: replay returned schedule %q, want %q in recorded order", i, got, want)
+		}
+	}
+	if n := requests.Load(); n != 3 {
+		t.Errorf("server got %d requests, want 3 while recording only", n)
+	}
+}
+
+func TestPassthrough(t *testing.T) {
+	var requests atomic.Int32
+	srv := apiServer(&requests)
+	defer srv.Close()
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	rec, err := New(path, ModePassthrough)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := mepost.NewClient(apiKey)
+	client.BaseURL = srv.URL
+	client.HTTPClient = rec.Client()
+	subscriber, _ := calls(t, client)
+	if err := rec.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if subscriber.EmailAddress != "alice@example.com" || requests.Load() != 3 {
+		t.Errorf("got subscriber %q after %d requests, want the API's responses", subscriber.EmailAddress, requests.Load())
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("passthrough wrote a cassette: %v", err)
+	}
+}
+
+func TestReplayMatching(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	cassette := `{"interactions": [
+		{"request": {"method": "POST", "path": "/v1/messages", "query": {"a": ["1", "2"]}, "body": "{\"subject\":\"Hi\",\"to\":[\"redacted-ff8d9819@example.com\"]}"},
+		 "response": {"statusCode": 201, "body": "{\"uuid\":\"s1\"}"}},
+		{"request": {"method": "GET", "path": "/v1/groups/redacted-ff8d9819@example.com"},
+		 "response": {"statusCode": 404}}
+	]}`
+	if err := os.WriteFile(path, []byte(cassette), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rec, err := New(path, ModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var gzipped bytes.Buffer
+	zw := gzip.NewWriter(&gzipped)
+	io.WriteString(zw, `{"to":["alice@example.com"],"subject":"Hi"}`)
+	zw.Close()
+
+	tests := []struct {
+		name     string
+		method   string
+		url      string
+		body     []byte
+		encoding string
+		status   int
+	}{
+		// Keys are sorted, whitespace dropped and recipients redacted before matching.
+		{"normalized body", "POST", "https://a.invalid/v1/messages?a=2&a=1", []byte(`{ "to": ["alice@example.com"], "subject": "Hi" }`), "", 201},
+		{"gzipped body", "POST", "https://b.invalid/v1/messages?a=1&a=2", gzipped.Bytes(), "gzip", 201},
+		{"redacted path", "GET", "https://a.invalid/v1/groups/alice@example.com", nil, "", 404},
+		{"other body", "POST", "https://a.invalid/v1/messages?a=1&a=2", []byte(`{"to":["bob@example.com"],"subject":"Hi"}`), "", 0},
+		{"other query", "POST", "https://a.invalid/v1/messages?a=1", []byte(`{"to":["alice@example.com"],"subject":"Hi"}`), "", 0},
+		{"other method", "DELETE", "https://a.invalid/v1/groups/alice@example.com", nil, "", 0},
+	}
+	for _, tt := range tests {
+		req, err := http.NewRequest(tt.method, tt.url, bytes.NewReader(tt.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tt.encoding != "" {
+			req.Header.Set("Content-Encoding", tt.encoding)
+		}
+		resp, err := rec.RoundTrip(req)
+		if tt.status == 0 {
+			if !errors.Is(err, ErrNoInteraction) {
+				t.Errorf("%s: got error %v, want ErrNoInteraction", tt.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s: got status %d, want %d", tt.name, resp.StatusCode, tt.status)
+		}
+	}
+}
+
+func TestRedaction(t *testing.T) {
+	if a, b := redactString("Alice@Example.com"), redactString("alice@example.com"); a != b || !strings.HasPrefix(a, "redacted-") {
+		t.Errorf("got placeholders %q and %q, want the same stable placeholder", a, b)
+	}
+	if got := redactString("not an address"); got != "not an address" {
+		t.Errorf("redacted %q", got)
+	}
+	alice := redactString("alice@example.com")
+	if got, want := redactPath("/v1/groups/g1/subscribers/alice%40example.com"), "/v1/groups/g1/subscribers/"+alice; got != want {
+		t.Errorf("got path %q, want %q", got, want)
+	}
+
+	body := `{"subject":"alice@example.com","to":[{"email":"alice@example.com","name":"Alice"}],"emails":["alice@example.com"],"n":1.50}`
+	want := `{"emails":["` + alice + `"],"n":1.50,"subject":"alice@example.com","to":[{"email":"` + alice + `","name":"Alice"}]}`
+	if got := normalizeBody([]byte(body)); got != want {
+		t.Errorf("got body\n%s\nwant\n%s", got, want)
+	}
+	if got := normalizeBody([]byte("plain text")); got != "plain text" {
+		t.Errorf("got non-JSON body %q", got)
+	}
+
+	header := redactHeader(http.Header{"Authorization": {apiKey}, "Cookie": {"c"}, "X-Request-Id": {"r"}})
+	if len(header) != 1 || header.Get("X-Request-Id") != "r" {
+		t.Errorf("got header %v, want only X-Request-Id", header)
+	}
+}
+
+func TestModeFromEnv(t *testing.T) {
+	for value, want := range map[string]Mode{"record": ModeRecord, "PASSTHROUGH": ModePassthrough, "replay": ModeReplay, "": ModeReplay} {
+		t.Setenv(ModeEnv, value)
+		if got := ModeFromEnv(); got != want {
+			t.Errorf("%s=%q: got mode %s, want %s", ModeEnv, value, got, want)
+		}
+	}
+}
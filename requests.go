@@ -56,7 +56,7 @@ type SendMarketingRequest struct {
 	Html          string            `json:"html,omitempty"`
 	IpGroup       string            `json:"ipGroup,omitempty"`
 	ReturnPath    string            `json:"returnPath,omitempty"`
-	ScheduledAt   string            `json:"scheduledAt,omitempty"`
+	ScheduledAt   *ScheduledTime    `json:"scheduledAt,omitempty"`
 	Subject       string            `json:"subject"`
 	Text          string            `json:"text,omitempty"`
 	To            []string          `json:"to"`
@@ -78,7 +78,7 @@ type SendTransactionalRequest struct {
 	Html          string            `json:"html,omitempty"`
 	IpGroup       string            `json:"ipGroup,omitempty"`
 	ReturnPath    string            `json:"returnPath,omitempty"`
-	ScheduledAt   string            `json:"scheduledAt,omitempty"`
+	ScheduledAt   *ScheduledTime    `json:"scheduledAt,omitempty"`
 	Subject       string            `json:"subject"`
 	Text          string            `json:"text,omitempty"`
 	To            []To              `json:"to"`
@@ -119,7 +119,7 @@ type MessageDto struct {
 	Html          string            `json:"html,omitempty"`
 	IpGroup       string            `json:"ipGroup,omitempty"`
 	ReturnPath    string            `json:"returnPath,omitempty"`
-	ScheduledAt   string            `json:"scheduledAt,omitempty"`
+	ScheduledAt   *ScheduledTime    `json:"scheduledAt,omitempty"`
 	Subject       string            `json:"subject"`
 	Text          string            `json:"text,omitempty"`
 	To            []To              `json:"to"`
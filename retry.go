@@ -0,0 +1,108 @@
+package mepost
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how failed requests are retried.
+//
+// Requests rejected with 429 Too Many Requests are retried for every method.
+// Network errors and 5xx responses are only retried for idempotent methods,
+// so that a message is never sent twice.
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first attempt. Zero disables retries.
+	MaxRetries int
+	// MinBackoff and MaxBackoff bound the exponential backoff between attempts.
+	// A Retry-After header longer than MaxBackoff stops retrying.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy is the retry policy of clients created with NewClient and
+// LoadConfig. It does not retry; setting MaxRetries, or MEPOST_MAX_RETRIES or
+// retry.maxRetries in a profile, enables retries with its backoff.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 0,
+	MinBackoff: 500 * time.Millisecond,
+	MaxBackoff: 10 * time.Second,
+}
+
+// backoff reports whether the request should be retried after the given attempt
+// failed with err, and how long to wait before retrying.
+func (p RetryPolicy) backoff(attempt int, method string, err error) (time.Duration, bool) {
+	if attempt >= p.MaxRetries || !retryable(method, err) {
+		return 0, false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		if wait, ok := retryAfter(apiErr.Header); ok {
+			if p.MaxBackoff > 0 && wait > p.MaxBackoff {
+				return 0, false
+			}
+			return wait, true
+		}
+	}
+	wait := p.MinBackoff << attempt
+	if wait <= 0 || (p.MaxBackoff > 0 && wait > p.MaxBackoff) {
+		wait = p.MaxBackoff
+	}
+	// Full jitter spreads out retries from concurrent callers.
+	if wait > 0 {
+		wait = time.Duration(rand.Int63n(int64(wait)) + 1)
+	}
+	return wait, true
+}
+
+func retryable(method string, err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusTooManyRequests:
+			return true
+		case http.StatusInternalServerError, http.StatusBadGateway,
+			http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return idempotent(method)
+		}
+		return false
+	}
+	// Only transport failures are retried; errors building or authenticating
+	// the request would fail again.
+	var urlErr *url.Error
+	return errors.As(err, &urlErr) && idempotent(method)
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date.
+func retryAfter(header http.Header) (time.Duration, bool) {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		wait := time.Until(t)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
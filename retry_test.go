@@ -0,0 +1,116 @@
+package mepost
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryable(t *testing.T) {
+	apiError := func(status int) error {
+		return &APIError{StatusCode: status, Header: http.Header{}}
+	}
+	transport := &url.Error{Op: "Get", URL: "https://api.example.com", Err: errors.New("connection reset")}
+	tests := []struct {
+		method string
+		err    error
+		want   bool
+	}{
+		{"GET", apiError(http.StatusTooManyRequests), true},
+		{"POST", apiError(http.StatusTooManyRequests), true},
+		{"GET", apiError(http.StatusInternalServerError), true},
+		{"DELETE", apiError(http.StatusBadGateway), true},
+		{"PUT", apiError(http.StatusServiceUnavailable), true},
+		{"GET", apiError(http.StatusGatewayTimeout), true},
+		{"POST", apiError(http.StatusInternalServerError), false},
+		{"POST", apiError(http.StatusBadGateway), false},
+		{"POST", apiError(http.StatusServiceUnavailable), false},
+		{"POST", apiError(http.StatusGatewayTimeout), false},
+		{"GET", apiError(http.StatusNotImplemented), false},
+		{"GET", apiError(http.StatusBadRequest), false},
+		{"GET", apiError(http.StatusUnauthorized), false},
+		{"GET", transport, true},
+		{"POST", transport, false},
+		{"GET", fmt.Errorf("error making request: %w", transport), true},
+		{"GET", context.Canceled, false},
+		{"GET", fmt.Errorf("error making request: %w", &url.Error{Op: "Get", Err: context.DeadlineExceeded}), false},
+		{"GET", errors.New("error marshalling request data"), false},
+	}
+	for _, tt := range tests {
+		if got := retryable(tt.method, tt.err); got != tt.want {
+			t.Errorf("retryable(%s, %v) = %v, want %v", tt.method, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	policy := RetryPolicy{MaxRetries: 3, MinBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	unavailable := &APIError{StatusCode: http.StatusServiceUnavailable, Header: http.Header{}}
+
+	for attempt, limit := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond} {
+		for i := 0; i < 100; i++ {
+			wait, ok := policy.backoff(attempt, "GET", unavailable)
+			if !ok || wait <= 0 || wait > limit {
+				t.Fatalf("attempt %d: got %v, %v, want a wait in (0, %v]", attempt, wait, ok, limit)
+			}
+		}
+	}
+	if _, ok := policy.backoff(3, "GET", unavailable); ok {
+		t.Error("retried after MaxRetries attempts")
+	}
+	for attempt := 0; attempt < 3; attempt++ {
+		if _, ok := policy.backoff(attempt, "POST", unavailable); ok {
+			t.Errorf("attempt %d: retried a POST after a 503", attempt)
+		}
+	}
+
+	limited := &APIError{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": {"1"}}}
+	if wait, ok := policy.backoff(0, "POST", limited); !ok || wait != time.Second {
+		t.Errorf("Retry-After: got %v, %v, want 1s, true", wait, ok)
+	}
+	limited.Header.Set("Retry-After", "5")
+	if _, ok := policy.backoff(0, "POST", limited); ok {
+		t.Error("retried although Retry-After exceeds MaxBackoff")
+	}
+
+	capped := RetryPolicy{MaxRetries: 10, MinBackoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond}
+	if wait, ok := capped.backoff(8, "GET", unavailable); !ok || wait > 300*time.Millisecond {
+		t.Errorf("got %v, %v, want a wait capped at MaxBackoff", wait, ok)
+	}
+}
+
+func TestRetries(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	c := NewClient("key")
+	c.BaseURL = srv.URL
+	if c.Retry.MaxRetries != 0 {
+		t.Fatalf("NewClient retries %d times by default", c.Retry.MaxRetries)
+	}
+	c.Outbound.IPs.List(ctx)
+	if n := requests.Swap(0); n != 1 {
+		t.Errorf("default client made %d requests, want 1", n)
+	}
+
+	c.Retry = RetryPolicy{MaxRetries: 2, MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+	c.Outbound.IPs.List(ctx)
+	if n := requests.Swap(0); n != 3 {
+		t.Errorf("GET made %d requests, want 3", n)
+	}
+	c.Outbound.IPGroups.Create(ctx, CreateIpGroupRequest{GroupName: "pool"})
+	if n := requests.Swap(0); n != 1 {
+		t.Errorf("POST made %d requests, want 1", n)
+	}
+}
@@ -0,0 +1,222 @@
+package mepost
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// SubscribersService manages the subscribers of email groups.
+type SubscribersService struct {
+	client *Client
+}
+
+// SubscriberStatus is a status subscribers can be filtered by.
+type SubscriberStatus string
+
+// Subscriber statuses.
+const (
+	SubscriberConfirmed    SubscriberStatus = "confirmed"
+	SubscriberUnconfirmed  SubscriberStatus = "unconfirmed"
+	SubscriberUnsubscribed SubscriberStatus = "unsubscribed"
+	SubscriberBounced      SubscriberStatus = "bounced"
+)
+
+// matches reports whether the subscriber has the status.
+func (st SubscriberStatus) matches(s *Subscriber) bool {
+	switch st {
+	case SubscriberConfirmed:
+		return s.Confirmed
+	case SubscriberUnconfirmed:
+		return !s.Confirmed
+	case SubscriberUnsubscribed:
+		return s.Unsubscribed
+	case SubscriberBounced:
+		return s.Bounced
+	}
+	return false
+}
+
+// SubscriberSortField is a field subscribers can be sorted by.
+type SubscriberSortField string
+
+// Subscriber sort fields.
+const (
+	SubscriberSortByEmail        SubscriberSortField = "emailAddress"
+	SubscriberSortByCreatedAt    SubscriberSortField = "createdAt"
+	SubscriberSortByUpdatedAt    SubscriberSortField = "updatedAt"
+	SubscriberSortBySubscribedAt SubscriberSortField = "subscribedAt"
+)
+
+// ListSubscribersOptions holds the options for listing the subscribers of a group.
+//
+// Filters and sorting are sent to the API and also applied to the returned page,
+// so results are correct even where the API does not support them. In that case
+// a page may hold fewer than Limit subscribers, Total still counts all
+// subscribers of the group, and sorting only orders the subscribers within the
+// page; use SubscribersService.ListAll to sort across pages.
+type ListSubscribersOptions struct {
+	// Limit is the maximum number of subscribers to return.
+	Limit int
+	// Page is the page number, starting at 1.
+	Page int
+	// Status matches subscribers with any of the given statuses.
+	Status []SubscriberStatus
+	// Email matches subscribers whose address contains the given text, ignoring case.
+	Email string
+	// Created and Updated restrict the creation and last update times.
+	Created TimeRange
+	Updated TimeRange
+	// SortBy and Order sort the results.
+	SortBy SubscriberSortField
+	Order  SortOrder
+}
+
+func (o *ListSubscribersOptions) query() url.Values {
+	query := pageQuery(o.Limit, o.Page)
+	for _, status := range o.Status {
+		query.Add("status", string(status))
+	}
+	if o.Email != "" {
+		query.Set("search", o.Email)
+	}
+	o.Created.setQuery(query, "created")
+	o.Updated.setQuery(query, "updated")
+	if o.SortBy != "" {
+		query.Set("sort", string(o.SortBy))
+	}
+	if o.Order != "" {
+		query.Set("order", string(o.Order))
+	}
+	return query
+}
+
+func (o *ListSubscribersOptions) match(s *Subscriber) bool {
+	if len(o.Status) > 0 {
+		matched := false
+		for _, status := range o.Status {
+			if status.matches(s) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if o.Email != "" && !strings.Contains(strings.ToLower(s.EmailAddress), strings.ToLower(o.Email)) {
+		return false
+	}
+	return o.Created.contains(s.CreatedAt.Time) && o.Updated.contains(s.UpdatedAt.Time)
+}
+
+// apply filters and sorts subscribers in place and returns the filtered slice.
+func (o *ListSubscribersOptions) apply(subscribers []Subscriber) []Subscriber {
+	filtered := subscribers[:0]
+	for i := range subscribers {
+		if o.match(&subscribers[i]) {
+			filtered = append(filtered, subscribers[i])
+		}
+	}
+	if o.SortBy == "" {
+		return filtered
+	}
+	sort.SliceStable(filtered, func(i, j int) bool {
+		a, b := &filtered[i], &filtered[j]
+		switch o.SortBy {
+		case SubscriberSortByEmail:
+			return less(strings.ToLower(a.EmailAddress), strings.ToLower(b.EmailAddress), o.Order)
+		case SubscriberSortByCreatedAt:
+			return less(a.CreatedAt.UnixNano(), b.CreatedAt.UnixNano(), o.Order)
+		case SubscriberSortByUpdatedAt:
+			return less(a.UpdatedAt.UnixNano(), b.UpdatedAt.UnixNano(), o.Order)
+		case SubscriberSortBySubscribedAt:
+			return less(a.SubscribedAt.UnixNano(), b.SubscribedAt.UnixNano(), o.Order)
+		}
+		return false
+	})
+	return filtered
+}
+
+// List retrieves a page of the subscribers of a group. opts may be nil.
+func (s *SubscribersService) List(ctx context.Context, groupID string, opts *ListSubscribersOptions) (*BaseResult[Subscriber], error) {
+	if opts == nil {
+		opts = &ListSubscribersOptions{}
+	}
+	response, err := s.list(ctx, groupID, opts)
+	if err != nil {
+		return response, err
+	}
+	response.Data = opts.apply(response.Data)
+	return response, nil
+}
+
+// ListAll retrieves every subscriber of a group that matches opts, following
+// pagination. opts.Limit sets the page size and defaults to 100; opts.Page is ignored.
+func (s *SubscribersService) ListAll(ctx context.Context, groupID string, opts *ListSubscribersOptions) ([]Subscriber, error) {
+	pageOpts := ListSubscribersOptions{}
+	if opts != nil {
+		pageOpts = *opts
+	}
+	if pageOpts.Limit <= 0 {
+		pageOpts.Limit = 100
+	}
+	var subscribers []Subscriber
+	for page := 1; ; page++ {
+		pageOpts.Page = page
+		response, err := s.list(ctx, groupID, &pageOpts)
+		if err != nil {
+			return nil, err
+		}
+		subscribers = append(subscribers, response.Data...)
+		if lastPage(len(response.Data), pageOpts.Limit, len(subscribers), response.Total) {
+			break
+		}
+	}
+	return pageOpts.apply(subscribers), nil
+}
+
+// list retrieves a page of subscribers without applying the filters locally.
+func (s *SubscribersService) list(ctx context.Context, groupID string, opts *ListSubscribersOptions) (*BaseResult[Subscriber], error) {
+	url, err := s.client.buildURL(opts.query(), "groups", groupID, "subscribers")
+	if err != nil {
+		return nil, err
+	}
+	response := &BaseResult[Subscriber]{}
+	err = s.client.makeRequest(ctx, OpListSubscribers, "GET", url, nil, response)
+	return response, err
+}
+
+// Get retrieves a subscriber of a group by email address.
+func (s *SubscribersService) Get(ctx context.Context, groupID, email string) (*Subscriber, error) {
+	url, err := s.client.buildURL(nil, "groups", groupID, "subscribers", email)
+	if err != nil {
+		return nil, err
+	}
+	response := &Subscriber{}
+	err = s.client.makeRequest(ctx, OpGetSubscriber, "GET", url, nil, response)
+	return response, err
+}
+
+// Create adds subscribers to a group.
+func (s *SubscribersService) Create(ctx context.Context, groupID string, request CreateSubscriberRequest) (bool, error) {
+	url, err := s.client.buildURL(nil, "groups", groupID, "subscribers")
+	if err != nil {
+		return false, err
+	}
+	var response bool
+	err = s.client.makeRequest(ctx, OpCreateSubscriber, "POST", url, request, &response)
+	return response, err
+}
+
+// Delete removes subscribers from a group.
+func (s *SubscribersService) Delete(ctx context.Context, groupID string, request DeleteSubscriberRequest) (bool, error) {
+	url, err := s.client.buildURL(nil, "groups", groupID, "subscribers")
+	if err != nil {
+		return false, err
+	}
+	var response bool
+	err = s.client.makeRequest(ctx, OpDeleteSubscriber, "DELETE", url, request, &response)
+	return response, err
+}
@@ -0,0 +1,199 @@
+package mepost
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ScheduleLayout is the layout used to send scheduled times to the Mepost API.
+// Scheduled times are always serialized in UTC.
+const ScheduleLayout = "2006-01-02T15:04:05.000Z"
+
+// MaxScheduleHorizon is how far in the future a message may be scheduled.
+const MaxScheduleHorizon = 30 * 24 * time.Hour
+
+var (
+	// ErrScheduleInPast is returned when a scheduled time has already passed.
+	ErrScheduleInPast = errors.New("mepost: scheduled time is in the past")
+	// ErrScheduleTooFar is returned when a scheduled time is beyond MaxScheduleHorizon.
+	ErrScheduleTooFar = errors.New("mepost: scheduled time is beyond the allowed horizon")
+)
+
+// ScheduledTime represents the time at which a message should be sent.
+type ScheduledTime struct {
+	time.Time
+}
+
+// ScheduleAt returns a ScheduledTime for t, or nil, meaning send immediately,
+// for the zero time.
+func ScheduleAt(t time.Time) *ScheduledTime {
+	if t.IsZero() {
+		return nil
+	}
+	return &ScheduledTime{Time: t}
+}
+
+// ScheduleIn returns a ScheduledTime d from now.
+func ScheduleIn(d time.Duration) *ScheduledTime {
+	return ScheduleAt(time.Now().Add(d))
+}
+
+// Validate checks that the scheduled time is neither in the past nor beyond MaxScheduleHorizon.
+func (s *ScheduledTime) Validate() error {
+	return s.validate(time.Now())
+}
+
+// checkSchedule clears a scheduled time that is set to the zero time, so that
+// it is omitted from the request instead of being sent as null, and validates it.
+func checkSchedule(s **ScheduledTime, now time.Time) error {
+	if *s != nil && (*s).IsZero() {
+		*s = nil
+	}
+	return (*s).validate(now)
+}
+
+func (s *ScheduledTime) validate(now time.Time) error {
+	if s == nil || s.IsZero() {
+		return nil
+	}
+	if s.Before(now) {
+		return fmt.Errorf("%w: %s", ErrScheduleInPast, s.UTC().Format(ScheduleLayout))
+	}
+	if s.After(now.Add(MaxScheduleHorizon)) {
+		return fmt.Errorf("%w: %s", ErrScheduleTooFar, s.UTC().Format(ScheduleLayout))
+	}
+	return nil
+}
+
+// MarshalJSON serializes the scheduled time in UTC using ScheduleLayout.
+func (s ScheduledTime) MarshalJSON() ([]byte, error) {
+	if s.IsZero() {
+		return []byte("null"), nil
+	}
+	return []byte(`"` + s.UTC().Format(ScheduleLayout) + `"`), nil
+}
+
+// UnmarshalJSON parses a scheduled time in RFC 3339 format.
+func (s *ScheduledTime) UnmarshalJSON(data []byte) error {
+	t, err := parseTime(data)
+	if err != nil {
+		return fmt.Errorf("error parsing scheduled time: %v", err)
+	}
+	if !t.IsZero() {
+		t = t.UTC()
+	}
+	s.Time = t
+	return nil
+}
+
+// MarshalText formats the scheduled time like MarshalJSON, without quotes.
+// The zero time is formatted as an empty string.
+func (s ScheduledTime) MarshalText() ([]byte, error) {
+	if s.IsZero() {
+		return []byte{}, nil
+	}
+	return []byte(s.UTC().Format(ScheduleLayout)), nil
+}
+
+// UnmarshalText parses a scheduled time like UnmarshalJSON.
+func (s *ScheduledTime) UnmarshalText(text []byte) error {
+	t, err := parseTimeText(string(text))
+	if err != nil {
+		return fmt.Errorf("error parsing scheduled time: %v", err)
+	}
+	if !t.IsZero() {
+		t = t.UTC()
+	}
+	s.Time = t
+	return nil
+}
+
+// Time represents a timestamp returned by the Mepost API. Empty strings and
+// null decode to the zero time instead of failing the surrounding decode.
+type Time struct {
+	time.Time
+}
+
+// timeLayouts lists the formats the API is known to emit, in the order they are tried.
+// Layouts without a zone are interpreted as UTC.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+// MarshalJSON serializes the time in RFC 3339 format, or null for the zero time.
+func (t Time) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return t.Time.MarshalJSON()
+}
+
+// UnmarshalJSON parses any of the timestamp formats emitted by the API.
+func (t *Time) UnmarshalJSON(data []byte) error {
+	parsed, err := parseTime(data)
+	if err != nil {
+		return fmt.Errorf("error parsing time: %v", err)
+	}
+	t.Time = parsed
+	return nil
+}
+
+// MarshalText formats the time in RFC 3339 format, or as an empty string for the zero time.
+func (t Time) MarshalText() ([]byte, error) {
+	if t.IsZero() {
+		return []byte{}, nil
+	}
+	return t.Time.MarshalText()
+}
+
+// UnmarshalText parses any of the timestamp formats emitted by the API. An
+// empty string decodes to the zero time.
+func (t *Time) UnmarshalText(text []byte) error {
+	parsed, err := parseTimeText(string(text))
+	if err != nil {
+		return fmt.Errorf("error parsing time: %v", err)
+	}
+	t.Time = parsed
+	return nil
+}
+
+// parseTime parses a JSON timestamp, treating null and empty strings as the zero time.
+func parseTime(data []byte) (time.Time, error) {
+	if string(data) == "null" {
+		return time.Time{}, nil
+	}
+	// Timestamps contain no escapes, so their quotes are stripped directly.
+	if n := len(data); n >= 2 && data[0] == '"' && data[n-1] == '"' && bytes.IndexByte(data, '\\') < 0 {
+		return parseTimeText(string(data[1 : n-1]))
+	}
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return time.Time{}, err
+	}
+	return parseTimeText(str)
+}
+
+// parseTimeText parses a timestamp, treating an empty string as the zero time.
+func parseTimeText(str string) (time.Time, error) {
+	if str == "" {
+		return time.Time{}, nil
+	}
+	return parseTimeString(str)
+}
+
+// parseTimeString parses str using the first matching layout in timeLayouts.
+func parseTimeString(str string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, str); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized time format %q", str)
+}
@@ -0,0 +1,150 @@
+package mepost
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTimeDecoding(t *testing.T) {
+	march := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		data string
+		want time.Time
+		ok   bool
+	}{
+		{`null`, time.Time{}, true},
+		{`""`, time.Time{}, true},
+		{`"2024-03-01T10:00:00.000Z"`, march, true},
+		{`"2024-03-01T11:00:00+01:00"`, march, true},
+		{`"2024-03-01 10:00:00"`, march, true},
+		{`"2024-03-01"`, day, true},
+		// Escaped strings are unquoted like encoding/json does.
+		{`"\u0032024-03-01"`, day, true},
+		{`"2024\u002d03\u002d01T10:00:00.000Z"`, march, true},
+		{`"2024-03-01T10:00:00\/"`, time.Time{}, false},
+		{`"\ud83d\ude00"`, time.Time{}, false},
+		{`"\u0001"`, time.Time{}, false},
+		{`"2024-03-01\x"`, time.Time{}, false},
+		{`1709287200`, time.Time{}, false},
+		{`"yesterday"`, time.Time{}, false},
+	}
+	for _, tt := range tests {
+		var got struct {
+			Time      Time           `json:"time"`
+			Scheduled *ScheduledTime `json:"scheduled"`
+		}
+		err := JSONCodec{}.Unmarshal([]byte(`{"time":`+tt.data+`,"scheduled":`+tt.data+`}`), &got)
+		if (err == nil) != tt.ok {
+			t.Errorf("decoding %s: got error %v", tt.data, err)
+			continue
+		}
+		if !tt.ok {
+			continue
+		}
+		if !got.Time.Equal(tt.want) {
+			t.Errorf("decoding %s: got time %v, want %v", tt.data, got.Time, tt.want)
+		}
+		if tt.data == "null" {
+			if got.Scheduled != nil {
+				t.Errorf("decoding null: got scheduled time %v", got.Scheduled)
+			}
+		} else if !got.Scheduled.Equal(tt.want) || (!tt.want.IsZero() && got.Scheduled.Location() != time.UTC) {
+			t.Errorf("decoding %s: got scheduled time %v, want %v in UTC", tt.data, got.Scheduled, tt.want)
+		}
+	}
+}
+
+// FuzzParseTime checks that timestamps are unquoted exactly like encoding/json
+// unquotes strings.
+func FuzzParseTime(f *testing.F) {
+	for _, seed := range []string{
+		`"2024-03-01T10:00:00.000Z"`,
+		`"2024-03-01"`,
+		`"\u0032024-03-01"`,
+		`"\ud83d\ude00"`,
+		`"\ud83d"`,
+		`"\u0001"`,
+		`"\x"`,
+		"\"\x01\"",
+		`""`,
+		`null`,
+	} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var str string
+		if !json.Valid(data) || json.Unmarshal(data, &str) != nil {
+			// Decoders only pass valid JSON strings and null.
+			return
+		}
+		want, wantErr := parseTimeText(str)
+		got, err := parseTime(data)
+		if (err == nil) != (wantErr == nil) || !got.Equal(want) {
+			t.Errorf("parseTime(%q) = %v, %v; unquoted by encoding/json: %v, %v", data, got, err, want, wantErr)
+		}
+	})
+}
+
+func TestScheduleBoundaries(t *testing.T) {
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	oslo := time.FixedZone("CET", 3600)
+	tests := []struct {
+		name string
+		at   *ScheduledTime
+		err  error
+	}{
+		{"nil", nil, nil},
+		{"zero", &ScheduledTime{}, nil},
+		{"one second ago", ScheduleAt(now.Add(-time.Second)), ErrScheduleInPast},
+		{"one nanosecond ago", ScheduleAt(now.Add(-1)), ErrScheduleInPast},
+		{"now", ScheduleAt(now), nil},
+		{"now in another zone", ScheduleAt(now.In(oslo)), nil},
+		{"in 30 days", ScheduleAt(now.Add(MaxScheduleHorizon)), nil},
+		{"in 30 days in another zone", ScheduleAt(now.Add(MaxScheduleHorizon).In(oslo)), nil},
+		{"in 30 days and a second", ScheduleAt(now.Add(MaxScheduleHorizon + time.Second)), ErrScheduleTooFar},
+		{"in 31 days", ScheduleAt(now.AddDate(0, 0, 31)), ErrScheduleTooFar},
+	}
+	for _, tt := range tests {
+		err := tt.at.validate(now)
+		if !errors.Is(err, tt.err) || (err == nil) != (tt.err == nil) {
+			t.Errorf("%s: got error %v, want %v", tt.name, err, tt.err)
+		}
+	}
+
+	if MaxScheduleHorizon != 30*24*time.Hour {
+		t.Errorf("got MaxScheduleHorizon %v, want 30 days", MaxScheduleHorizon)
+	}
+	err := ScheduleAt(now.Add(MaxScheduleHorizon + time.Second).In(oslo)).validate(now)
+	if want := "mepost: scheduled time is beyond the allowed horizon: 2024-03-31T10:00:01.000Z"; err == nil || err.Error() != want {
+		t.Errorf("got error %v, want %q", err, want)
+	}
+}
+
+func TestCheckSchedule(t *testing.T) {
+	now := time.Now()
+	zero := &ScheduledTime{}
+	if err := checkSchedule(&zero, now); err != nil || zero != nil {
+		t.Errorf("got %v and error %v for the zero time, want it cleared", zero, err)
+	}
+	past := ScheduleAt(now.Add(-time.Minute))
+	if err := checkSchedule(&past, now); !errors.Is(err, ErrScheduleInPast) || past == nil {
+		t.Errorf("got error %v for a past time", err)
+	}
+
+	// Sends are rejected before any request is made.
+	c := NewClient("key")
+	c.BaseURL = "http://127.0.0.1:0"
+	_, err := c.Messages.SendTransactional(context.Background(), SendTransactionalRequest{
+		FromEmail:   "noreply@example.com",
+		Subject:     "Later",
+		To:          []To{{Email: "alice@example.com"}},
+		ScheduledAt: ScheduleIn(MaxScheduleHorizon + time.Hour),
+	})
+	if !errors.Is(err, ErrScheduleTooFar) {
+		t.Errorf("got error %v, want ErrScheduleTooFar", err)
+	}
+}
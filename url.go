@@ -0,0 +1,51 @@
+package mepost
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ErrEmptyPathParameter is returned when a path parameter such as a group ID is empty.
+var ErrEmptyPathParameter = errors.New("mepost: empty path parameter")
+
+// buildURL joins the escaped path segments to the base URL and appends the encoded query.
+func (c *Client) buildURL(query url.Values, segments ...string) (string, error) {
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(c.BaseURL, "/"))
+	for _, segment := range segments {
+		if segment == "" {
+			return "", ErrEmptyPathParameter
+		}
+		b.WriteByte('/')
+		b.WriteString(escapePathSegment(segment))
+	}
+	if len(query) > 0 {
+		b.WriteByte('?')
+		b.WriteString(query.Encode())
+	}
+	return b.String(), nil
+}
+
+// escapePathSegment escapes s so that it is sent as exactly one path segment.
+// In addition to url.PathEscape, it escapes "+", which some servers decode as a
+// space, and dot segments, which would otherwise be resolved as relative paths.
+func escapePathSegment(s string) string {
+	if s == "." || s == ".." {
+		return strings.ReplaceAll(s, ".", "%2E")
+	}
+	return strings.ReplaceAll(url.PathEscape(s), "+", "%2B")
+}
+
+// pageQuery returns the query parameters for a paginated list. Zero values are omitted.
+func pageQuery(limit, page int) url.Values {
+	query := url.Values{}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	if page > 0 {
+		query.Set("page", strconv.Itoa(page))
+	}
+	return query
+}
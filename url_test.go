@@ -0,0 +1,81 @@
+package mepost
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+)
+
+func TestEscapePathSegment(t *testing.T) {
+	tests := []struct {
+		segment string
+		want    string
+	}{
+		{"plain", "plain"},
+		{"alice+tag@example.com", "alice%2Btag@example.com"},
+		{"a/b", "a%2Fb"},
+		{"100%", "100%25"},
+		{"what?", "what%3F"},
+		{"#tag", "%23tag"},
+		{".", "%2E"},
+		{"..", "%2E%2E"},
+		{"...", "..."},
+		{"a.b", "a.b"},
+		{"with space", "with%20space"},
+		{"grüppe ✓", "gr%C3%BCppe%20%E2%9C%93"},
+		{"../../admin", "..%2F..%2Fadmin"},
+	}
+	for _, tt := range tests {
+		got := escapePathSegment(tt.segment)
+		if got != tt.want {
+			t.Errorf("escapePathSegment(%q) = %q, want %q", tt.segment, got, tt.want)
+		}
+		if unescaped, err := url.PathUnescape(got); err != nil || unescaped != tt.segment {
+			t.Errorf("escapePathSegment(%q) unescapes to %q, %v", tt.segment, unescaped, err)
+		}
+	}
+}
+
+func TestBuildURL(t *testing.T) {
+	c := &Client{BaseURL: "https://api.example.com/v1/"}
+	tests := []struct {
+		name     string
+		query    url.Values
+		segments []string
+		want     string
+		err      error
+	}{
+		{
+			name:     "segments",
+			segments: []string{"groups", "g/1", "subscribers", "a+b@example.com"},
+			want:     "https://api.example.com/v1/groups/g%2F1/subscribers/a%2Bb@example.com",
+		},
+		{
+			name:     "dot segments",
+			segments: []string{"outbound", "ip-group", "info", ".."},
+			want:     "https://api.example.com/v1/outbound/ip-group/info/%2E%2E",
+		},
+		{
+			name:     "query",
+			query:    url.Values{"search": {"a+b&c=d #x"}, "limit": {"10"}},
+			segments: []string{"groups"},
+			want:     "https://api.example.com/v1/groups?limit=10&search=a%2Bb%26c%3Dd+%23x",
+		},
+		{
+			name:     "empty segment",
+			segments: []string{"groups", "", "subscribers"},
+			err:      ErrEmptyPathParameter,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.buildURL(tt.query, tt.segments...)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}